@@ -4,6 +4,8 @@ require (
 	github.com/charlievieth/fastwalk v1.0.14
 	github.com/gdamore/tcell/v2 v2.9.0
 	github.com/junegunn/go-shellwords v0.0.0-20250127100254-2aa3b3277741
+	github.com/klauspost/compress v1.18.0
+	github.com/koron/gomigemo v0.0.0-20210612172932-2cc85a8ebac1
 	github.com/mattn/go-isatty v0.0.22
 	github.com/rivo/uniseg v0.4.7
 	golang.org/x/sys v0.35.0
@@ -14,7 +16,6 @@ require (
 	github.com/gdamore/encoding v1.0.1 // indirect
 	github.com/koron/gelatin v0.0.0-20160729020448-88d6a03ce765 // indirect
 	github.com/koron/go-skkdict v0.0.0-20160727125427-1bfa372d61d2 // indirect
-	github.com/koron/gomigemo v0.0.0-20210612172932-2cc85a8ebac1 // indirect
 	github.com/lucasb-eyer/go-colorful v1.2.0 // indirect
 	github.com/mattn/go-runewidth v0.0.16 // indirect
 	golang.org/x/text v0.28.0 // indirect
@@ -110,6 +110,12 @@ func main() {
 		return
 	}
 
+	if options.Remote != nil {
+		code, err := fzf.RunRemote(options)
+		exit(code, err)
+		return
+	}
+
 	code, err := fzf.Run(options)
 	exit(code, err)
 }
@@ -157,6 +157,7 @@ _fzf_opts_completion() {
     --height
     --highlight-line
     --history
+    --history-context
     --history-size
     --hscroll-off
     --id-nth
@@ -169,11 +170,13 @@ _fzf_opts_completion() {
     --keep-right
     --layout
     --listen
+    --listen-tls
     --listen-unsafe
     --list-border
     --list-label
     --list-label-pos
     --literal
+    --macro-file
     --man
     --margin
     --marker
@@ -197,10 +200,13 @@ _fzf_opts_completion() {
     --prompt
     --raw
     --read0
+    --record
+    --replay
     --scheme
     --scroll-off
     --scrollbar
     --separator
+    --sequence-timeout
     --smart-case
     --style
     --sync
@@ -211,6 +217,7 @@ _fzf_opts_completion() {
     --tmux
     --track
     --version
+    --vi-mode
     --walker
     --walker-root
     --walker-skip
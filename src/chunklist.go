@@ -1,6 +1,10 @@
 package fzf
 
-import "sync"
+import (
+	"math"
+	"sort"
+	"sync"
+)
 
 // Chunk is a list of Items whose size has the upper limit of chunkSize
 type Chunk struct {
@@ -8,8 +12,9 @@ type Chunk struct {
 	count int
 }
 
-// ItemBuilder is a closure type that builds Item object from byte array
-type ItemBuilder func(*Item, []byte) bool
+// ItemBuilder is a closure type that builds Item object from byte array read
+// from the input source of the given ID
+type ItemBuilder func(*Item, []byte, int) bool
 
 // ChunkList is a list of Chunks
 type ChunkList struct {
@@ -28,8 +33,8 @@ func NewChunkList(cache *ChunkCache, trans ItemBuilder) *ChunkList {
 		cache:  cache}
 }
 
-func (c *Chunk) push(trans ItemBuilder, data []byte) bool {
-	if trans(&c.items[c.count], data) {
+func (c *Chunk) push(trans ItemBuilder, data []byte, source int) bool {
+	if trans(&c.items[c.count], data, source) {
 		c.count++
 		return true
 	}
@@ -79,15 +84,15 @@ func CountItems(cs []*Chunk) int {
 	return cs[0].count + chunkSize*(len(cs)-2) + cs[len(cs)-1].count
 }
 
-// Push adds the item to the list
-func (cl *ChunkList) Push(data []byte) bool {
+// Push adds the item read from the input source of the given ID to the list
+func (cl *ChunkList) Push(data []byte, source int) bool {
 	cl.mutex.Lock()
 
 	if len(cl.chunks) == 0 || cl.lastChunk().IsFull() {
 		cl.chunks = append(cl.chunks, &Chunk{})
 	}
 
-	ret := cl.lastChunk().push(cl.trans, data)
+	ret := cl.lastChunk().push(cl.trans, data, source)
 	cl.mutex.Unlock()
 	return ret
 }
@@ -114,6 +119,53 @@ func (cl *ChunkList) ForEachItem(fn func(*Item), done func()) {
 	cl.mutex.Unlock()
 }
 
+// ForEachItemFrom applies fn to each item whose index is not less than the
+// given index
+func (cl *ChunkList) ForEachItemFrom(index int32, fn func(*Item)) {
+	cl.mutex.Lock()
+	start := sort.Search(len(cl.chunks), func(i int) bool {
+		return cl.chunks[i].lastIndex(math.MinInt32) > index
+	})
+	for _, chunk := range cl.chunks[start:] {
+		for i := 0; i < chunk.count; i++ {
+			if item := &chunk.items[i]; item.Index() >= index {
+				fn(item)
+			}
+		}
+	}
+	cl.mutex.Unlock()
+}
+
+// Filter rebuilds the list with the items for which fn returns true. The items
+// are copied to new chunks so that the existing snapshots are not affected,
+// and fn is called on the copies so that it can update them. The done
+// callback runs under the lock to safely update shared state.
+func (cl *ChunkList) Filter(fn func(*Item) bool, done func()) {
+	cl.mutex.Lock()
+	chunks := []*Chunk{}
+	for _, chunk := range cl.chunks {
+		for i := 0; i < chunk.count; i++ {
+			if len(chunks) == 0 || chunks[len(chunks)-1].IsFull() {
+				chunks = append(chunks, &Chunk{})
+			}
+			last := chunks[len(chunks)-1]
+			last.items[last.count] = chunk.items[i]
+			if fn(&last.items[last.count]) {
+				last.count++
+			}
+		}
+	}
+	if len(chunks) > 0 && chunks[len(chunks)-1].count == 0 {
+		chunks = chunks[:len(chunks)-1]
+	}
+	cl.cache.retire(cl.chunks...)
+	cl.chunks = chunks
+	if done != nil {
+		done()
+	}
+	cl.mutex.Unlock()
+}
+
 // Snapshot returns immutable snapshot of the ChunkList
 func (cl *ChunkList) Snapshot(tail int) ([]*Chunk, int, bool) {
 	cl.mutex.Lock()
@@ -11,7 +11,7 @@ func TestChunkList(t *testing.T) {
 	// FIXME global
 	sortCriteria = []criterion{byScore, byLength}
 
-	cl := NewChunkList(NewChunkCache(), func(item *Item, s []byte) bool {
+	cl := NewChunkList(NewChunkCache(), func(item *Item, s []byte, source int) bool {
 		item.text = util.ToChars(s)
 		return true
 	})
@@ -23,8 +23,8 @@ func TestChunkList(t *testing.T) {
 	}
 
 	// Add some data
-	cl.Push([]byte("hello"))
-	cl.Push([]byte("world"))
+	cl.Push([]byte("hello"), 0)
+	cl.Push([]byte("world"), 0)
 
 	// Previously created snapshot should remain the same
 	if len(snapshot) > 0 {
@@ -52,7 +52,7 @@ func TestChunkList(t *testing.T) {
 
 	// Add more data
 	for i := range chunkSize * 2 {
-		cl.Push(fmt.Appendf(nil, "item %d", i))
+		cl.Push(fmt.Appendf(nil, "item %d", i), 0)
 	}
 
 	// Previous snapshot should remain the same
@@ -70,8 +70,8 @@ func TestChunkList(t *testing.T) {
 		t.Error("Unexpected number of items")
 	}
 
-	cl.Push([]byte("hello"))
-	cl.Push([]byte("world"))
+	cl.Push([]byte("hello"), 0)
+	cl.Push([]byte("world"), 0)
 
 	lastChunkCount := snapshot[len(snapshot)-1].count
 	if lastChunkCount != 2 {
@@ -80,13 +80,13 @@ func TestChunkList(t *testing.T) {
 }
 
 func TestChunkListTail(t *testing.T) {
-	cl := NewChunkList(NewChunkCache(), func(item *Item, s []byte) bool {
+	cl := NewChunkList(NewChunkCache(), func(item *Item, s []byte, source int) bool {
 		item.text = util.ToChars(s)
 		return true
 	})
 	total := chunkSize*2 + chunkSize/2
 	for i := range total {
-		cl.Push(fmt.Appendf(nil, "item %d", i))
+		cl.Push(fmt.Appendf(nil, "item %d", i), 0)
 	}
 
 	snapshot, count, changed := cl.Snapshot(0)
@@ -114,3 +114,36 @@ func TestChunkListTail(t *testing.T) {
 	snapshot, count, changed = cl.Snapshot(tail)
 	assertCount(tail, true)
 }
+
+func TestChunkListFilter(t *testing.T) {
+	cl := NewChunkList(NewChunkCache(), func(item *Item, s []byte, source int) bool {
+		item.text = util.ToChars(s)
+		item.text.Index = int32(source)
+		return true
+	})
+	total := chunkSize + chunkSize/2
+	for i := range total {
+		cl.Push(fmt.Appendf(nil, "item %d", i), i%3)
+	}
+	before, _, _ := cl.Snapshot(0)
+
+	done := false
+	cl.Filter(func(item *Item) bool {
+		return item.Index() != 1
+	}, func() { done = true })
+
+	snapshot, count, _ := cl.Snapshot(0)
+	if !done || count != total-total/3 || CountItems(snapshot) != count {
+		t.Errorf("Unexpected count: %d", count)
+	}
+	for _, item := range GetItems(snapshot, count) {
+		if item.Index() == 1 {
+			t.Errorf("Item should have been removed: %s", item.text.ToString())
+		}
+	}
+
+	// Existing snapshot should not be affected
+	if CountItems(before) != total || before[0].items[1].Index() != 1 {
+		t.Error("Snapshot should not have changed")
+	}
+}
@@ -19,6 +19,9 @@ const (
 	readerPollIntervalStep = 5 * time.Millisecond
 	readerPollIntervalMax  = 50 * time.Millisecond
 
+	// Interval between the updates of the duplicates hidden by --dedup-keep=last
+	dedupUpdateInterval = 200 * time.Millisecond
+
 	// Terminal
 	initialDelay      = 20 * time.Millisecond
 	initialDelayTac   = 100 * time.Millisecond
@@ -29,6 +32,12 @@ const (
 	maxPatternLength  = 1000
 	maxMulti          = math.MaxInt32
 
+	// Key sequences
+	defaultSequenceTimeout = 1000 * time.Millisecond
+
+	// Undo
+	maxUndoHistory = 1000
+
 	// Background processes
 	maxBgProcesses          = 30
 	maxBgProcessesPerAction = 3
@@ -61,6 +70,8 @@ const (
 const (
 	EvtReadNew util.EventType = iota
 	EvtReadFin
+	EvtPushItems
+	EvtHeadlessSearch
 	EvtSearchNew
 	EvtSearchProgress
 	EvtSearchFin
@@ -6,6 +6,7 @@ import (
 	"maps"
 	"math"
 	"os"
+	"runtime"
 	"sync"
 	"time"
 
@@ -39,9 +40,9 @@ func (r revision) compatible(other revision) bool {
 	return r.major == other.major
 }
 
-func buildItemTransformer(opts *Options) func(*Item) string {
+func buildItemTransformer(opts *Options, sources *sourceTable) func(*Item) string {
 	if opts.AcceptNth != nil {
-		fn := opts.AcceptNth(opts.Delimiter)
+		fn := opts.AcceptNth(opts.Delimiter, sources.name)
 		return func(item *Item) string {
 			return item.acceptNth(opts.Ansi, opts.Delimiter, fn)
 		}
@@ -53,7 +54,7 @@ func buildItemTransformer(opts *Options) func(*Item) string {
 
 // Run starts fzf
 func Run(opts *Options) (int, error) {
-	if opts.Filter == nil {
+	if opts.Filter == nil && len(opts.Replay) == 0 && opts.renderer == nil {
 		if opts.useTmux() {
 			return runTmux(os.Args, opts)
 		}
@@ -70,6 +71,16 @@ func Run(opts *Options) (int, error) {
 		return ExitError, err
 	}
 
+	// Fail early if the input file cannot be read, as the reader does not
+	// report the error
+	if len(opts.InputFile) > 0 {
+		file, err := os.Open(opts.InputFile)
+		if err != nil {
+			return ExitError, err
+		}
+		file.Close()
+	}
+
 	defer util.RunAtExitFuncs()
 
 	// Output channel given
@@ -85,6 +96,9 @@ func Run(opts *Options) (int, error) {
 	// Event channel
 	eventBox := util.NewEventBox()
 
+	// Input sources
+	sources := newSourceTable(opts.Sources)
+
 	// ANSI code processor
 	ansiProcessor := func(data []byte) (util.Chars, *[]ansiOffset) {
 		return util.ToChars(data), nil
@@ -152,17 +166,55 @@ func Run(opts *Options) (int, error) {
 		item.text.TrimTrailingWhitespaces(int(maxColorOffset))
 	}
 
+	// Deduplication
+	dedup := newDeduplicator(opts)
+	denyMutex := sync.Mutex{}
+	duplicates := make(map[int32]struct{}) // Items superseded by later occurrences
+	// Read-only snapshot of the duplicates shared by the patterns. It is
+	// replaced, never modified, when the pending duplicates are applied.
+	visibleDuplicates := make(map[int32]struct{})
+	duplicatesUpdated := false
+	duplicatesScheduled := false
+	var duplicatesApplied time.Time
+	deduplicate := func(data []byte, index int32) bool {
+		keep, prev := dedup.check(data, index)
+		if prev != nil {
+			denyMutex.Lock()
+			duplicates[*prev] = struct{}{}
+			duplicatesUpdated = true
+			if opts.Tail > 0 && len(duplicates) > 2*opts.Tail {
+				// Forget the duplicates that are already evicted by --tail
+				minIndex := index - int32(opts.Tail)
+				for dupIndex := range duplicates {
+					if dupIndex < minIndex {
+						delete(duplicates, dupIndex)
+					}
+				}
+			}
+			denyMutex.Unlock()
+		}
+		return keep
+	}
+
 	var nthTransformer func([]Token, int32) string
 	if opts.WithNth == nil {
-		chunkList = NewChunkList(cache, func(item *Item, data []byte) bool {
+		chunkList = NewChunkList(cache, func(item *Item, data []byte, source int) bool {
+			if !deduplicate(data, itemIndex) {
+				return false
+			}
+			sources.mark(itemIndex, source)
 			item.text, item.colors = ansiProcessor(data)
 			item.text.Index = itemIndex
 			itemIndex++
 			return true
 		})
 	} else {
-		nthTransformer = opts.WithNth(opts.Delimiter)
-		chunkList = NewChunkList(cache, func(item *Item, data []byte) bool {
+		nthTransformer = opts.WithNth(opts.Delimiter, sources.name)
+		chunkList = NewChunkList(cache, func(item *Item, data []byte, source int) bool {
+			if !deduplicate(data, itemIndex) {
+				return false
+			}
+			sources.mark(itemIndex, source)
 			if nthTransformer == nil {
 				item.text, item.colors = ansiProcessor(data)
 			} else {
@@ -184,7 +236,7 @@ func Run(opts *Options) (int, error) {
 	var initialEnv []string
 	initialReload := opts.extractReloadOnStart()
 	if opts.Filter == nil {
-		terminal, err = NewTerminal(opts, eventBox, executor)
+		terminal, err = NewTerminal(opts, eventBox, executor, sources)
 		if err != nil {
 			return ExitError, err
 		}
@@ -197,17 +249,28 @@ func Run(opts *Options) (int, error) {
 	}
 
 	// Reader
-	streamingFilter := opts.Filter != nil && !sort && !opts.Tac && !opts.Sync && opts.Bench == 0
+	streamingFilter := opts.Filter != nil && !sort && !opts.Tac && !opts.Sync && opts.Bench == 0 && !(opts.Dedup && opts.DedupLast)
 	var reader *Reader
 	var ingestionStart time.Time
 	if !streamingFilter {
-		reader = NewReader(func(data []byte) bool {
-			return chunkList.Push(data)
-		}, eventBox, executor, opts.ReadZero, opts.Filter == nil)
+		push := func(data []byte, source int) bool {
+			return chunkList.Push(data, source)
+		}
+		inputChan := opts.Input
+		if terminal != nil && terminal.recorder != nil {
+			push = func(data []byte, source int) bool {
+				terminal.recorder.recordInput(data)
+				return chunkList.Push(data, source)
+			}
+		}
+		if terminal != nil && terminal.replay != nil {
+			inputChan = terminal.replay.inputChan()
+		}
+		reader = NewReader(push, eventBox, executor, opts.ReadZero, opts.Delimiter.csvSeparator(), opts.InputDecompress, opts.Filter == nil)
 
 		ingestionStart = time.Now()
 		readyChan := make(chan bool)
-		go reader.ReadSource(opts.Input, opts.WalkerRoot, opts.WalkerOpts, opts.WalkerSkip, initialReload, initialEnv, readyChan)
+		go reader.ReadSource(inputChan, opts.Sources, opts.InputFile, opts.WalkerRoot, opts.WalkerOpts, opts.WalkerSkip, initialReload, initialEnv, readyChan)
 		<-readyChan
 	}
 
@@ -231,15 +294,18 @@ func Run(opts *Options) (int, error) {
 	nth := opts.Nth
 	inputRevision := revision{}
 	snapshotRevision := revision{}
+	var searchSeq int64
 	patternCache := make(map[string]*Pattern)
-	denyMutex := sync.Mutex{}
 	denylist := make(map[int32]struct{})
+	pushed := newPushIndex(opts)
 	clearDenylist := func() {
 		denyMutex.Lock()
-		if len(denylist) > 0 {
+		if len(denylist) > 0 || len(visibleDuplicates) > 0 || len(duplicates) > 0 {
 			patternCache = make(map[string]*Pattern)
 		}
 		denylist = make(map[int32]struct{})
+		visibleDuplicates = maps.Clone(duplicates)
+		duplicatesUpdated = false
 		denyMutex.Unlock()
 	}
 	if opts.HeaderLines > math.MaxInt32 {
@@ -249,11 +315,31 @@ func Run(opts *Options) (int, error) {
 	headerUpdated := false
 	patternBuilder := func(runes []rune) *Pattern {
 		denyMutex.Lock()
+		if duplicatesUpdated {
+			// The cached patterns refer to a stale snapshot of the duplicates.
+			// Instead of replacing it on every new duplicate, we do it
+			// periodically while reading.
+			if elapsed := time.Since(duplicatesApplied); elapsed >= dedupUpdateInterval {
+				patternCache = make(map[string]*Pattern)
+				visibleDuplicates = maps.Clone(duplicates)
+				duplicatesUpdated = false
+				duplicatesScheduled = false
+				duplicatesApplied = time.Now()
+			} else if !duplicatesScheduled {
+				// Make sure that the pending duplicates are applied even if
+				// no more input arrives
+				duplicatesScheduled = true
+				time.AfterFunc(dedupUpdateInterval-elapsed, func() {
+					eventBox.Set(EvtReadNew, (*string)(nil))
+				})
+			}
+		}
 		denylistCopy := maps.Clone(denylist)
+		duplicatesSnapshot := visibleDuplicates
 		denyMutex.Unlock()
 		return BuildPattern(cache, patternCache,
 			opts.Fuzzy, opts.FuzzyAlgo, opts.Extended, opts.Case, opts.Normalize, forward, withPos,
-			opts.Filter == nil, nth, opts.Delimiter, inputRevision, runes, denylistCopy, headerLines)
+			opts.Filter == nil, nth, opts.Delimiter, inputRevision, runes, denylistCopy, duplicatesSnapshot, headerLines, sources)
 	}
 	matcher := NewMatcher(cache, patternBuilder, sort, opts.Tac, eventBox, inputRevision, opts.Threads)
 
@@ -266,33 +352,41 @@ func Run(opts *Options) (int, error) {
 		pattern := patternBuilder([]rune(*opts.Filter))
 		matcher.sort = pattern.sortable
 
-		transformer := buildItemTransformer(opts)
+		transformer := buildItemTransformer(opts, sources)
 
 		found := false
+		var readErr error
 		if streamingFilter {
 			slab := util.MakeSlab(slab16Size, slab32Size)
 			mutex := sync.Mutex{}
 			reader := NewReader(
-				func(runes []byte) bool {
+				func(runes []byte, source int) bool {
 					item := Item{}
-					if chunkList.trans(&item, runes) {
-						if item.Index() < headerLines {
-							return false
-						}
-						mutex.Lock()
+					// Items from multiple sources can be pushed concurrently
+					mutex.Lock()
+					defer mutex.Unlock()
+					if chunkList.trans(&item, runes, source) && item.Index() >= headerLines {
 						if result, _, _ := pattern.MatchItem(&item, false, slab); result.item != nil {
 							opts.Printer(transformer(&item))
 							found = true
 						}
-						mutex.Unlock()
 					}
 					return false
-				}, eventBox, executor, opts.ReadZero, false)
-			reader.ReadSource(opts.Input, opts.WalkerRoot, opts.WalkerOpts, opts.WalkerSkip, initialReload, initialEnv, nil)
+				}, eventBox, executor, opts.ReadZero, opts.Delimiter.csvSeparator(), opts.InputDecompress, false)
+			reader.ReadSource(opts.Input, opts.Sources, opts.InputFile, opts.WalkerRoot, opts.WalkerOpts, opts.WalkerSkip, initialReload, initialEnv, nil)
+			readErr = reader.readError()
 		} else {
 			eventBox.Unwatch(EvtReadNew)
 			eventBox.WaitFor(EvtReadFin)
+			readErr = reader.readError()
 			ingestionTime := time.Since(ingestionStart)
+			if opts.Dedup && opts.DedupLast {
+				// Rebuild the pattern to exclude the superseded duplicates
+				denyMutex.Lock()
+				duplicatesApplied = time.Time{}
+				denyMutex.Unlock()
+				pattern = patternBuilder([]rune(*opts.Filter))
+			}
 
 			// NOTE: Streaming filter is inherently not compatible with --tail
 			snapshot, _, _ := chunkList.Snapshot(opts.Tail)
@@ -300,6 +394,12 @@ func Run(opts *Options) (int, error) {
 			if opts.Bench > 0 {
 				// Benchmark mode: repeat scan for the given duration
 				totalItems := CountItems(snapshot)
+
+				// Memory in use after ingestion. Memory-mapped input is not
+				// counted as it's not allocated on the heap.
+				var memStats runtime.MemStats
+				runtime.GC()
+				runtime.ReadMemStats(&memStats)
 				var matchCount int
 				var times []time.Duration
 				deadline := time.Now().Add(opts.Bench)
@@ -326,14 +426,15 @@ func Run(opts *Options) (int, error) {
 				}
 				avg := total / time.Duration(len(times))
 				selectivity := float64(matchCount) / float64(totalItems) * 100
-				fmt.Printf("  %d iterations  avg: %.2fms  min: %.2fms  max: %.2fms  total: %.2fs  items: %d  matches: %d (%.2f%%)  ingestion: %.2fms\n",
+				fmt.Printf("  %d iterations  avg: %.2fms  min: %.2fms  max: %.2fms  total: %.2fs  items: %d  matches: %d (%.2f%%)  ingestion: %.2fms  heap: %.2fMB\n",
 					len(times),
 					float64(avg.Microseconds())/1000,
 					float64(minD.Microseconds())/1000,
 					float64(maxD.Microseconds())/1000,
 					total.Seconds(),
 					totalItems, matchCount, selectivity,
-					float64(ingestionTime.Microseconds())/1000)
+					float64(ingestionTime.Microseconds())/1000,
+					float64(memStats.HeapAlloc)/1024/1024)
 				return ExitOk, nil
 			}
 
@@ -345,6 +446,9 @@ func Run(opts *Options) (int, error) {
 				found = true
 			}
 		}
+		if readErr != nil {
+			return ExitError, readErr
+		}
 		if found {
 			return ExitOk, nil
 		}
@@ -402,17 +506,45 @@ func Run(opts *Options) (int, error) {
 	var snapshot []*Chunk
 	var count int
 	restart := func(command commandSpec, environ []string) {
+		dedup.clear()
+		denyMutex.Lock()
+		duplicates = make(map[int32]struct{})
+		denyMutex.Unlock()
 		if !useSnapshot {
 			clearDenylist()
 		}
 		reading = true
 		headerUpdated = false
 		startTick = ticks
-		chunkList.Clear()
-		itemIndex = 0
+		pushed.clear()
+		source := sources.find(command.source)
+		if source >= 0 {
+			// Keep the items from the other sources. Their indexes are left
+			// intact so that the selection keyed by them stays valid, and the
+			// new items are appended after them.
+			runs := []sourceRun{}
+			chunkList.Filter(func(item *Item) bool {
+				index := item.Index()
+				id := sources.lookup(index)
+				if id == source || !deduplicate(stringBytes(item.AsString(false)), index) {
+					return false
+				}
+				runs = appendSourceRun(runs, index, id)
+				return true
+			}, func() {
+				sources.reset(runs)
+			})
+		} else {
+			itemIndex = 0
+			chunkList.Clear()
+			sources.reset(nil)
+		}
 		inputRevision.bumpMajor()
+		if terminal.recorder != nil {
+			terminal.recorder.stopInput()
+		}
 		readyChan := make(chan bool)
-		go reader.restart(command, environ, readyChan)
+		go reader.restart(command, source, environ, readyChan)
 		<-readyChan
 	}
 
@@ -443,6 +575,52 @@ func Run(opts *Options) (int, error) {
 					err = quitSignal.err
 					stop = true
 					return
+				case EvtHeadlessSearch:
+					// Searches requested via the server run against the current
+					// snapshot without going through the interactive matcher
+					for _, request := range terminal.searchQueue.drain() {
+						pattern := patternBuilder(request.query)
+						chunks := snapshot
+						go func() {
+							request.result <- matcher.Search(chunks, pattern, sort, request.cancelled)
+						}()
+					}
+				case EvtPushItems:
+					// Items pushed via the server are processed the same way as the
+					// items from the reader
+					bump := false
+					for _, request := range terminal.pushQueue.drain() {
+						if request.op != pushAppend {
+							if removed := pushed.remove(chunkList, request.lines, headerLines); len(removed) > 0 {
+								denyMutex.Lock()
+								for _, index := range removed {
+									denylist[index] = struct{}{}
+								}
+								denyMutex.Unlock()
+								// The replacement lines should not be dropped as
+								// duplicates of the removed ones
+								dedup.forget(removed)
+								bump = true
+							}
+						}
+						if request.op != pushRemove {
+							for _, line := range request.lines {
+								chunkList.Push(line, noSource)
+							}
+						}
+					}
+					if bump {
+						// Free the removed items instead of keeping them hidden
+						dropped := pushed.compact(chunkList, count)
+						denyMutex.Lock()
+						for _, index := range dropped {
+							delete(denylist, index)
+						}
+						patternCache = make(map[string]*Pattern)
+						denyMutex.Unlock()
+						inputRevision.bumpMinor()
+					}
+					fallthrough
 				case EvtReadNew, EvtReadFin:
 					if evt == EvtReadFin && nextCommand != nil {
 						restart(*nextCommand, nextEnviron)
@@ -450,7 +628,13 @@ func Run(opts *Options) (int, error) {
 						nextEnviron = nil
 						break
 					} else {
-						reading = reading && evt == EvtReadNew
+						reading = reading && evt != EvtReadFin
+					}
+					if !reading {
+						// Apply the pending duplicates right away
+						denyMutex.Lock()
+						duplicatesApplied = time.Time{}
+						denyMutex.Unlock()
 					}
 					if useSnapshot && evt == EvtReadFin { // reload-sync
 						clearDenylist()
@@ -468,7 +652,7 @@ func Run(opts *Options) (int, error) {
 						snapshotRevision = inputRevision
 					}
 					total = count
-					terminal.UpdateCount(max(0, total-int(headerLines)), !reading, value.(*string))
+					terminal.UpdateCount(max(0, total-int(headerLines)), !reading, value.(*string), reader.readError())
 					if headerLines > 0 && !headerUpdated {
 						terminal.UpdateHeader(GetItems(snapshot, int(headerLines)))
 						headerUpdated = total >= int(headerLines)
@@ -477,7 +661,7 @@ func Run(opts *Options) (int, error) {
 						determine(!reading)
 					}
 					if !useSnapshot || evt == EvtReadFin {
-						matcher.Reset(snapshot, input(), false, !reading, sort, snapshotRevision)
+						matcher.Reset(snapshot, input(), false, !reading, sort, snapshotRevision, searchSeq)
 					}
 
 				case EvtSearchNew:
@@ -488,6 +672,7 @@ func Run(opts *Options) (int, error) {
 					withNthChanged := false
 					switch val := value.(type) {
 					case searchRequest:
+						searchSeq = val.seq
 						sort = val.sort
 						command = val.command
 						environ = val.environ
@@ -577,7 +762,7 @@ func Run(opts *Options) (int, error) {
 						}
 					}
 					if headerLinesChanged {
-						terminal.UpdateCount(max(0, total-int(headerLines)), !reading, nil)
+						terminal.UpdateCount(max(0, total-int(headerLines)), !reading, nil, nil)
 						if headerLines > 0 {
 							terminal.UpdateHeader(GetItems(snapshot, int(headerLines)))
 						} else {
@@ -586,7 +771,7 @@ func Run(opts *Options) (int, error) {
 					} else if withNthChanged && headerLines > 0 {
 						terminal.UpdateHeader(GetItems(snapshot, int(headerLines)))
 					}
-					matcher.Reset(snapshot, input(), true, !reading, sort, snapshotRevision)
+					matcher.Reset(snapshot, input(), true, !reading, sort, snapshotRevision, searchSeq)
 					delay = false
 
 				case EvtSearchProgress:
@@ -611,7 +796,7 @@ func Run(opts *Options) (int, error) {
 									if len(opts.Expect) > 0 {
 										opts.Printer("")
 									}
-									transformer := buildItemTransformer(opts)
+									transformer := buildItemTransformer(opts, sources)
 									for i := range count {
 										opts.Printer(transformer(merger.Get(i).item))
 									}
@@ -0,0 +1,68 @@
+package fzf
+
+import (
+	"bufio"
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"io"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+var (
+	gzipMagic  = []byte{0x1f, 0x8b}
+	bzip2Magic = []byte("BZh")
+	zstdMagic  = []byte{0x28, 0xb5, 0x2f, 0xfd}
+)
+
+// decompressor keeps the first error from the underlying decompressing
+// reader, as Reader.feed stops reading on an error without reporting it
+type decompressor struct {
+	reader  io.Reader
+	release func()
+	err     error
+}
+
+func (d *decompressor) Read(p []byte) (int, error) {
+	n, err := d.reader.Read(p)
+	if err != nil && err != io.EOF && d.err == nil {
+		d.err = err
+	}
+	return n, err
+}
+
+// decompressReader returns a reader that stream-decompresses the data from
+// src if it starts with the magic bytes of gzip, bzip2, or zstd format.
+// Otherwise, the data is passed through as is. The format is determined only
+// by the magic bytes, so the data with a corrupt header is not passed through
+// but reported as an error. Close should be called to release the resources
+// of the decompressor.
+func decompressReader(src io.Reader) (*decompressor, error) {
+	buffered := bufio.NewReaderSize(src, readerBufferSize)
+	magic, _ := buffered.Peek(len(zstdMagic))
+	switch {
+	case bytes.HasPrefix(magic, gzipMagic):
+		reader, err := gzip.NewReader(buffered)
+		if err != nil {
+			return nil, err
+		}
+		return &decompressor{reader: reader, release: func() { reader.Close() }}, nil
+	case bytes.HasPrefix(magic, bzip2Magic) && len(magic) > 3 && magic[3] >= '1' && magic[3] <= '9':
+		return &decompressor{reader: bzip2.NewReader(buffered)}, nil
+	case bytes.HasPrefix(magic, zstdMagic):
+		reader, err := zstd.NewReader(buffered, zstd.WithDecoderConcurrency(1))
+		if err != nil {
+			return nil, err
+		}
+		return &decompressor{reader: reader, release: reader.Close}, nil
+	}
+	return &decompressor{reader: buffered}, nil
+}
+
+// Close releases the resources of the decompressor
+func (d *decompressor) Close() {
+	if d.release != nil {
+		d.release()
+	}
+}
@@ -0,0 +1,104 @@
+package fzf
+
+import (
+	"hash/maphash"
+	"maps"
+	"sync"
+)
+
+// deduplicator keeps track of the keys of the input lines to detect duplicates.
+// Only the 64-bit hashes of the keys are stored to bound the memory usage.
+// Unless --tail is given, seen grows with the number of unique lines.
+type deduplicator struct {
+	nth       []Range
+	delimiter Delimiter
+	last      bool
+	tail      int
+	seed      maphash.Seed
+	mutex     sync.Mutex
+	seen      map[uint64]int32
+}
+
+func newDeduplicator(opts *Options) *deduplicator {
+	if !opts.Dedup {
+		return nil
+	}
+	return &deduplicator{
+		nth:       opts.DedupNth,
+		delimiter: opts.Delimiter,
+		last:      opts.DedupLast,
+		tail:      opts.Tail,
+		seed:      maphash.MakeSeed(),
+		seen:      make(map[uint64]int32)}
+}
+
+func (d *deduplicator) hash(data []byte) uint64 {
+	if len(d.nth) == 0 {
+		return maphash.Bytes(d.seed, data)
+	}
+	tokens := Transform(Tokenize(byteString(data), d.delimiter), d.nth)
+	return maphash.String(d.seed, StripLastDelimiter(JoinTokens(tokens), d.delimiter))
+}
+
+// check registers the line to be added with the given index. It returns false
+// if the line should be dropped, and the index of the previous occurrence
+// that should be dropped instead when the last occurrence is to be kept.
+func (d *deduplicator) check(data []byte, index int32) (bool, *int32) {
+	if d == nil {
+		return true, nil
+	}
+	key := d.hash(data)
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	prev, found := d.seen[key]
+	if found && d.tail > 0 && prev < index-int32(d.tail) {
+		// The previous occurrence is already evicted by --tail
+		found = false
+	}
+	if found && !d.last {
+		return false, nil
+	}
+	d.seen[key] = index
+	if d.tail > 0 && len(d.seen) > 2*d.tail {
+		d.evict(index - int32(d.tail))
+	}
+	if found {
+		return true, &prev
+	}
+	return true, nil
+}
+
+// evict forgets the keys of the lines whose indexes are less than minIndex
+func (d *deduplicator) evict(minIndex int32) {
+	for key, index := range d.seen {
+		if index < minIndex {
+			delete(d.seen, key)
+		}
+	}
+}
+
+// forget forgets the keys of the removed lines so that the same lines can be
+// added again
+func (d *deduplicator) forget(indexes []int32) {
+	if d == nil || len(indexes) == 0 {
+		return
+	}
+	removed := make(map[int32]struct{}, len(indexes))
+	for _, index := range indexes {
+		removed[index] = struct{}{}
+	}
+	d.mutex.Lock()
+	maps.DeleteFunc(d.seen, func(_ uint64, index int32) bool {
+		_, prs := removed[index]
+		return prs
+	})
+	d.mutex.Unlock()
+}
+
+func (d *deduplicator) clear() {
+	if d != nil {
+		d.mutex.Lock()
+		d.seen = make(map[uint64]int32)
+		d.mutex.Unlock()
+	}
+}
@@ -0,0 +1,57 @@
+package fzf
+
+import (
+	"testing"
+)
+
+func TestDeduplicator(t *testing.T) {
+	if d := newDeduplicator(defaultOptions()); d != nil {
+		t.Error("should be nil when --dedup is not set")
+	}
+	var nilDedup *deduplicator
+	if keep, prev := nilDedup.check([]byte("foo"), 0); !keep || prev != nil {
+		t.Error("nil deduplicator should keep everything")
+	}
+
+	check := func(d *deduplicator, lines []string, expected []bool, prevs []int32) {
+		t.Helper()
+		for idx, line := range lines {
+			keep, prev := d.check([]byte(line), int32(idx))
+			if keep != expected[idx] {
+				t.Errorf("%q: %v", line, keep)
+			}
+			if prevs[idx] < 0 && prev != nil || prevs[idx] >= 0 && (prev == nil || *prev != prevs[idx]) {
+				t.Errorf("%q: %v", line, prev)
+			}
+		}
+	}
+
+	opts := defaultOptions()
+	opts.Dedup = true
+	d := newDeduplicator(opts)
+	lines := []string{"a 1", "b 2", "a 1", "a 3"}
+	check(d, lines, []bool{true, true, false, true}, []int32{-1, -1, -1, -1})
+
+	// Key fields
+	opts.DedupNth, _ = splitNth("1")
+	d = newDeduplicator(opts)
+	check(d, lines, []bool{true, true, false, false}, []int32{-1, -1, -1, -1})
+
+	// Keep the last occurrence
+	opts.DedupLast = true
+	d = newDeduplicator(opts)
+	check(d, lines, []bool{true, true, true, true}, []int32{-1, -1, 0, 2})
+
+	// Clear
+	d.clear()
+	check(d, lines[:1], []bool{true}, []int32{-1})
+
+	// Previous occurrences evicted by --tail are forgotten
+	opts.DedupLast = false
+	opts.Tail = 1
+	d = newDeduplicator(opts)
+	check(d, []string{"a", "b", "a", "a"}, []bool{true, true, true, false}, []int32{-1, -1, -1, -1})
+	if len(d.seen) > 2*opts.Tail {
+		t.Errorf("too many keys: %d", len(d.seen))
+	}
+}
@@ -1,18 +1,59 @@
 package fzf
 
 import (
+	"encoding/json"
 	"errors"
+	"hash/crc32"
+	"io"
 	"os"
+	"path/filepath"
+	"slices"
 	"strings"
+	"time"
 )
 
-// History struct represents input history
+// The history file is compacted to maxSize entries when it grows beyond
+// maxSize * historyCompactionFactor entries
+const historyCompactionFactor = 2
+
+// historyEntry is an entry of the history file. An entry in the structured
+// format is a JSON object on a single line, and a plain entry is the query
+// itself with no context.
+type historyEntry struct {
+	Time    int64  `json:"time"`
+	Cwd     string `json:"cwd,omitempty"`
+	Context string `json:"context"`
+	Query   string `json:"query"`
+}
+
+func parseHistoryEntry(line string) historyEntry {
+	if strings.HasPrefix(line, `{"`) {
+		var entry historyEntry
+		if err := json.Unmarshal([]byte(line), &entry); err == nil && len(entry.Context) > 0 {
+			return entry
+		}
+	}
+	return historyEntry{Query: line}
+}
+
+// History struct represents input history. Multiple processes can share the
+// same history file; each entry is appended to the file while holding an
+// exclusive lock, and the entries appended by the other processes are merged
+// on navigation.
+//
+// If context is set, the entries are written in the structured format, and
+// only the entries with the same context are navigated. The entries with the
+// same query are deduplicated, leaving the most recent one.
 type History struct {
 	path     string
+	context  string
 	lines    []string
 	modified map[int]string
 	maxSize  int
 	cursor   int
+	size     int64     // Size of the file when it was last read
+	modTime  time.Time // Modification time of the file when it was last read
+	checksum uint32    // Checksum of the file content when it was last read
 }
 
 // NewHistory returns the pointer to a new History struct
@@ -24,30 +65,210 @@ func NewHistory(path string, maxSize int) (*History, error) {
 		return errors.New("invalid history file: " + e.Error())
 	}
 
-	// Read history file
-	data, err := os.ReadFile(path)
-	if err != nil {
-		// If it doesn't exist, check if we can create a file with the name
-		if os.IsNotExist(err) {
-			data = []byte{}
-			if err := os.WriteFile(path, data, 0600); err != nil {
-				return nil, fmtError(err)
-			}
-		} else {
+	// If it doesn't exist, check if we can create a file with the name
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		if err := os.WriteFile(path, []byte{}, 0600); err != nil {
 			return nil, fmtError(err)
 		}
 	}
-	// Split lines and limit the maximum number of lines
-	lines := strings.Split(strings.Trim(string(data), "\n"), "\n")
-	if len(lines[len(lines)-1]) > 0 {
-		lines = append(lines, "")
-	}
-	return &History{
+
+	h := &History{
 		path:     path,
 		maxSize:  maxSize,
-		lines:    lines,
-		modified: make(map[int]string),
-		cursor:   len(lines) - 1}, nil
+		lines:    []string{""},
+		modified: make(map[int]string)}
+	data, err := h.read()
+	if err != nil {
+		return nil, fmtError(err)
+	}
+	h.merge(data)
+	return h, nil
+}
+
+// setContext changes the context of the history and reloads the entries
+func (h *History) setContext(context string) error {
+	if h.context == context {
+		return nil
+	}
+	h.context = context
+	h.lines = []string{""}
+	h.modified = make(map[int]string)
+	h.cursor = 0
+	h.size = 0
+	h.checksum = 0
+	data, err := h.read()
+	if err != nil {
+		return err
+	}
+	h.merge(data)
+	return nil
+}
+
+// parseHistory splits the content of the history file into lines
+func parseHistory(data []byte) []string {
+	str := strings.Trim(string(data), "\n")
+	if len(str) == 0 {
+		return []string{}
+	}
+	return strings.Split(str, "\n")
+}
+
+// openLocked opens the history file and locks it. The file can be replaced
+// by another process compacting it while we wait for the lock, so it is
+// reopened until the locked file is the one at the path.
+func (h *History) openLocked(flag int, exclusive bool) (*os.File, error) {
+	for {
+		f, err := os.OpenFile(h.path, flag, 0600)
+		if err != nil {
+			return nil, err
+		}
+		if err := lockFile(f, exclusive); err != nil {
+			f.Close()
+			return nil, err
+		}
+		info, err := f.Stat()
+		current, errCurrent := os.Stat(h.path)
+		if err != nil || errCurrent != nil || os.SameFile(info, current) {
+			return f, nil
+		}
+		unlockFile(f)
+		f.Close()
+	}
+}
+
+// read returns the content of the history file while holding a shared lock
+func (h *History) read() ([]byte, error) {
+	f, err := h.openLocked(os.O_RDONLY, false)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	defer unlockFile(f)
+	return io.ReadAll(f)
+}
+
+// visibleQueries returns the queries of the entries in the current context
+func (h *History) visibleQueries(lines []string) []string {
+	queries := []string{}
+	for _, line := range lines {
+		entry := parseHistoryEntry(line)
+		if len(h.context) == 0 || entry.Context == h.context {
+			queries = append(queries, entry.Query)
+		}
+	}
+	return queries
+}
+
+// merge updates the entries with the content of the history file. The
+// entries being edited and the cursor are kept in place.
+func (h *History) merge(data []byte) {
+	count := len(h.lines) - 1
+	input := h.lines[count]
+
+	// Original index of each entry, or -1 if it is new
+	var queries []string
+	var origins []int
+	appended := int64(len(data)) >= h.size && crc32.ChecksumIEEE(data[:h.size]) == h.checksum
+	if appended {
+		queries = append(h.lines[:count], h.visibleQueries(parseHistory(data[h.size:]))...)
+		for i := range queries {
+			origins = append(origins, i)
+			if i >= count {
+				origins[i] = -1
+			}
+		}
+	} else {
+		// The file was compacted or rewritten
+		queries = h.visibleQueries(parseHistory(data))
+		origins = make([]int, len(queries))
+		for i := range origins {
+			origins[i] = -1
+		}
+	}
+
+	if len(h.context) > 0 {
+		// Remove the duplicates, keeping the last one
+		seen := make(map[string]bool)
+		var uniqueQueries []string
+		var uniqueOrigins []int
+		for i := len(queries) - 1; i >= 0; i-- {
+			if !seen[queries[i]] {
+				seen[queries[i]] = true
+				uniqueQueries = append(uniqueQueries, queries[i])
+				uniqueOrigins = append(uniqueOrigins, origins[i])
+			}
+		}
+		slices.Reverse(uniqueQueries)
+		slices.Reverse(uniqueOrigins)
+		queries = uniqueQueries
+		origins = uniqueOrigins
+	}
+	if len(queries) > h.maxSize {
+		trimmed := len(queries) - h.maxSize
+		queries = queries[trimmed:]
+		origins = origins[trimmed:]
+	}
+
+	// Map the original indexes to the new ones
+	remap := make(map[int]int)
+	if appended {
+		for i, origin := range origins {
+			if origin >= 0 {
+				remap[origin] = i
+			}
+		}
+	} else if len(h.context) > 0 {
+		// Queries are unique
+		index := make(map[string]int)
+		for i, query := range queries {
+			index[query] = i
+		}
+		for i, query := range h.lines[:count] {
+			if idx, found := index[query]; found {
+				remap[i] = idx
+			}
+		}
+	} else {
+		// Assume that the entries were removed from the beginning
+		shift := len(queries) - count
+		for i := range count {
+			if i+shift >= 0 {
+				remap[i] = i + shift
+			}
+		}
+	}
+
+	if h.cursor >= count {
+		h.cursor = len(queries)
+	} else if idx, found := remap[h.cursor]; found {
+		h.cursor = idx
+	} else {
+		h.cursor = min(h.cursor, len(queries))
+	}
+	modified := make(map[int]string)
+	for idx, str := range h.modified {
+		if newIdx, found := remap[idx]; found {
+			modified[newIdx] = str
+		}
+	}
+	h.modified = modified
+	h.lines = append(queries, input)
+	h.size = int64(len(data))
+	h.checksum = crc32.ChecksumIEEE(data)
+	if info, err := os.Stat(h.path); err == nil {
+		h.modTime = info.ModTime()
+	}
+}
+
+// sync merges the entries appended by the other processes
+func (h *History) sync() {
+	info, err := os.Stat(h.path)
+	if err != nil || info.Size() == h.size && info.ModTime().Equal(h.modTime) {
+		return
+	}
+	if data, err := h.read(); err == nil {
+		h.merge(data)
+	}
 }
 
 func (h *History) append(line string) error {
@@ -56,12 +277,108 @@ func (h *History) append(line string) error {
 		return nil
 	}
 
-	lines := append(h.lines[:len(h.lines)-1], line)
-	if len(lines) > h.maxSize {
-		lines = lines[len(lines)-h.maxSize:]
+	f, err := h.openLocked(os.O_RDWR|os.O_CREATE, true)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	defer unlockFile(f)
+
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return err
+	}
+	if len(h.context) > 0 {
+		cwd, _ := os.Getwd()
+		bytes, err := json.Marshal(historyEntry{time.Now().Unix(), cwd, h.context, line})
+		if err != nil {
+			return err
+		}
+		line = string(bytes)
 	}
-	h.lines = append(lines, "")
-	return os.WriteFile(h.path, []byte(strings.Join(h.lines, "\n")), 0600)
+	entry := []byte(line + "\n")
+	if len(data) > 0 && data[len(data)-1] != '\n' {
+		entry = append([]byte{'\n'}, entry...)
+	}
+	if _, err := f.Write(entry); err != nil {
+		return err
+	}
+	data = append(data, entry...)
+
+	if lines := parseHistory(data); len(lines) > h.maxSize*historyCompactionFactor {
+		lines = compactHistory(lines)
+		data = []byte(strings.Join(lines[max(0, len(lines)-h.maxSize):], "\n") + "\n")
+		if err := h.replace(f, data); err != nil {
+			return err
+		}
+	}
+
+	h.merge(data)
+	h.lines[len(h.lines)-1] = ""
+	h.cursor = len(h.lines) - 1
+	return nil
+}
+
+// replace replaces the content of the locked history file with the data. The
+// data is written to a temporary file which is then renamed to the history
+// file, so that the history is not lost on a crash. If the temporary file
+// cannot be renamed, as on Windows where an open file cannot be replaced, the
+// file is rewritten in place.
+func (h *History) replace(f *os.File, data []byte) error {
+	path, err := filepath.EvalSymlinks(h.path)
+	if err != nil {
+		return err
+	}
+	temp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	if info, err := f.Stat(); err == nil {
+		temp.Chmod(info.Mode().Perm())
+	}
+	_, err = temp.Write(data)
+	if err == nil {
+		err = temp.Sync()
+	}
+	if errClose := temp.Close(); err == nil {
+		err = errClose
+	}
+	if err == nil {
+		err = os.Rename(temp.Name(), path)
+	}
+	if err == nil {
+		return nil
+	}
+	os.Remove(temp.Name())
+	if _, ok := err.(*os.LinkError); !ok {
+		return err
+	}
+	if err := f.Truncate(0); err != nil {
+		return err
+	}
+	_, err = f.WriteAt(data, 0)
+	return err
+}
+
+// compactHistory removes the structured entries that are followed by the
+// entries with the same context and query
+func compactHistory(lines []string) []string {
+	type key struct{ context, query string }
+	seen := make(map[key]bool)
+	compacted := []string{}
+	for i := len(lines) - 1; i >= 0; i-- {
+		entry := parseHistoryEntry(lines[i])
+		if len(entry.Context) > 0 {
+			k := key{entry.Context, entry.Query}
+			if seen[k] {
+				continue
+			}
+			seen[k] = true
+		}
+		compacted = append(compacted, lines[i])
+	}
+	slices.Reverse(compacted)
+	return compacted
 }
 
 func (h *History) override(str string) {
@@ -80,7 +397,15 @@ func (h *History) current() string {
 	return h.lines[h.cursor]
 }
 
+// entries returns the entries after merging the ones appended by the other
+// processes
+func (h *History) entries() []string {
+	h.sync()
+	return slices.Clone(h.lines[:len(h.lines)-1])
+}
+
 func (h *History) previous() string {
+	h.sync()
 	if h.cursor > 0 {
 		h.cursor--
 	}
@@ -88,6 +413,7 @@ func (h *History) previous() string {
 }
 
 func (h *History) next() string {
+	h.sync()
 	if h.cursor < len(h.lines)-1 {
 		h.cursor++
 	}
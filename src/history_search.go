@@ -0,0 +1,190 @@
+package fzf
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/junegunn/fzf/src/tui"
+	"github.com/junegunn/fzf/src/util"
+)
+
+// historySearch is the state of the overlay list opened by search-history
+type historySearch struct {
+	query   []rune
+	entries []string // Most recent first
+	matches []historyMatch
+	cursor  int
+	offset  int
+	pattern func([]rune) *Pattern
+	slab    *util.Slab
+}
+
+type historyMatch struct {
+	text      []rune
+	positions []int
+	score     int
+}
+
+func newHistoryPatternBuilder(opts *Options) func([]rune) *Pattern {
+	cache := NewChunkCache()
+	patternCache := make(map[string]*Pattern)
+	return func(runes []rune) *Pattern {
+		return BuildPattern(cache, patternCache,
+			opts.Fuzzy, opts.FuzzyAlgo, opts.Extended, opts.Case, opts.Normalize, true,
+			true, false, nil, Delimiter{}, revision{}, runes, nil, nil, 0, nil)
+	}
+}
+
+func newHistorySearch(lines []string, pattern func([]rune) *Pattern) *historySearch {
+	// Remove the duplicates, keeping the most recent one
+	seen := make(map[string]bool)
+	entries := []string{}
+	for i := len(lines) - 1; i >= 0; i-- {
+		if line := lines[i]; len(line) > 0 && !seen[line] {
+			seen[line] = true
+			entries = append(entries, line)
+		}
+	}
+	s := &historySearch{entries: entries, pattern: pattern, slab: util.MakeSlab(slab16Size, slab32Size)}
+	s.update()
+	return s
+}
+
+// update filters the entries with the query. The matches with the same
+// score are ordered by recency.
+func (s *historySearch) update() {
+	s.matches = []historyMatch{}
+	s.cursor = 0
+	s.offset = 0
+
+	pattern := s.pattern(s.query)
+	for _, entry := range s.entries {
+		if pattern.IsEmpty() {
+			s.matches = append(s.matches, historyMatch{text: []rune(entry)})
+			continue
+		}
+		item := Item{text: util.ToChars([]byte(entry))}
+		result, _, pos, score := pattern.matchItem(&item, true, s.slab)
+		if result.item == nil {
+			continue
+		}
+		match := historyMatch{text: []rune(entry), score: score}
+		if pos != nil {
+			match.positions = slices.Clone(*pos)
+			slices.Sort(match.positions)
+		}
+		s.matches = append(s.matches, match)
+	}
+	slices.SortStableFunc(s.matches, func(a, b historyMatch) int {
+		return b.score - a.score
+	})
+}
+
+func (s *historySearch) move(o int) {
+	s.cursor = util.Constrain(s.cursor+o, 0, max(0, len(s.matches)-1))
+}
+
+func (s *historySearch) current() (string, bool) {
+	if s.cursor < len(s.matches) {
+		return string(s.matches[s.cursor].text), true
+	}
+	return "", false
+}
+
+// updateHistorySearch handles the key event in the history search overlay.
+// It returns true when the overlay should be closed, along with the selected
+// entry if any.
+func (t *Terminal) updateHistorySearch(event tui.Event) (bool, *string) {
+	s := t.historySearch
+	actions := t.keymap[event.Comparable()]
+	if len(actions) == 0 {
+		if event.Type == tui.Rune {
+			s.query = append(s.query, event.Char)
+			s.update()
+		}
+		return false, nil
+	}
+
+	vmove := func(o int) {
+		if t.layout != layoutDefault {
+			o *= -1
+		}
+		s.move(o)
+	}
+	switch actions[0].t {
+	case actChar:
+		if event.Type == tui.Rune {
+			s.query = append(s.query, event.Char)
+			s.update()
+		}
+	case actBackwardDeleteChar, actBackwardDeleteCharEof:
+		if len(s.query) > 0 {
+			s.query = s.query[:len(s.query)-1]
+			s.update()
+		}
+	case actUnixLineDiscard, actClearQuery:
+		s.query = []rune{}
+		s.update()
+	case actUp, actUpMatch, actPrevHistory:
+		vmove(1)
+	case actDown, actDownMatch, actNextHistory:
+		vmove(-1)
+	case actPageUp, actHalfPageUp:
+		vmove(max(1, t.maxItems()-1))
+	case actPageDown, actHalfPageDown:
+		vmove(-max(1, t.maxItems()-1))
+	case actAccept, actAcceptNonEmpty, actAcceptOrPrintQuery:
+		if entry, ok := s.current(); ok {
+			return true, &entry
+		}
+		return true, nil
+	case actAbort, actCancel, actSearchHistory:
+		return true, nil
+	}
+	return false, nil
+}
+
+// printHistorySearch renders the history search overlay in the list window
+func (t *Terminal) printHistorySearch(startLine int, maxy int) {
+	s := t.historySearch
+	height := maxy - startLine + 1
+	if s.cursor < s.offset {
+		s.offset = s.cursor
+	} else if height > 0 && s.cursor >= s.offset+height {
+		s.offset = s.cursor - height + 1
+	}
+
+	maxWidth := t.window.Width() - t.pointerLen - t.markerLen - 1
+	for line := startLine; line <= maxy; line++ {
+		t.move(line, 0, true)
+		t.markOtherLine(line)
+		idx := s.offset + line - startLine
+		if idx >= len(s.matches) {
+			continue
+		}
+		match := s.matches[idx]
+		current := idx == s.cursor
+		colBase, colMatch := tui.ColNormal, tui.ColMatch
+		if current {
+			colBase, colMatch = tui.ColCurrent, tui.ColCurrentMatch
+			t.window.CPrint(tui.ColCurrentPointer, t.pointer)
+		} else {
+			t.gutter(false, false)
+		}
+		t.window.CPrint(colBase, t.markerEmpty)
+
+		text, _ := t.trimRight(match.text, maxWidth)
+		width := 0
+		for i, r := range text {
+			color := colBase
+			if _, found := slices.BinarySearch(match.positions, i); found {
+				color = colMatch
+			}
+			t.window.CPrint(color, string(r))
+			width += t.displayWidth([]rune{r})
+		}
+		if current && t.highlightLine && maxWidth > width {
+			t.window.CPrint(colBase, strings.Repeat(" ", maxWidth-width))
+		}
+	}
+}
@@ -1,8 +1,12 @@
 package fzf
 
 import (
+	"fmt"
 	"os"
+	"path/filepath"
 	"runtime"
+	"strconv"
+	"sync"
 	"testing"
 )
 
@@ -65,3 +69,228 @@ func TestHistory(t *testing.T) {
 		compare(maxHistory-1, "foobarbaz")
 	}
 }
+
+func TestHistoryConcurrentWriters(t *testing.T) {
+	f, _ := os.CreateTemp("", "fzf-history")
+	f.Close()
+	defer os.Remove(f.Name())
+
+	writers := 8
+	entries := 50
+	var wg sync.WaitGroup
+	for w := range writers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			h, err := NewHistory(f.Name(), writers*entries)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			for i := range entries {
+				if err := h.append(fmt.Sprintf("writer %d: %d", w, i)); err != nil {
+					t.Error(err)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	h, _ := NewHistory(f.Name(), writers*entries)
+	if len(h.lines) != writers*entries+1 {
+		t.Errorf("Expected: %d, actual: %d", writers*entries+1, len(h.lines))
+	}
+	// Entries from each writer should be in order
+	next := make(map[string]int)
+	for _, line := range h.lines[:len(h.lines)-1] {
+		var w, i int
+		if _, err := fmt.Sscanf(line, "writer %d: %d", &w, &i); err != nil {
+			t.Fatalf("Corrupted entry: %q", line)
+		}
+		key := strconv.Itoa(w)
+		if next[key] != i {
+			t.Errorf("Expected: %d, actual: %d", next[key], i)
+		}
+		next[key] = i + 1
+	}
+}
+
+func TestHistoryMerge(t *testing.T) {
+	f, _ := os.CreateTemp("", "fzf-history")
+	f.Close()
+	defer os.Remove(f.Name())
+
+	h1, _ := NewHistory(f.Name(), 10)
+	h2, _ := NewHistory(f.Name(), 10)
+	h1.append("foo")
+	h2.append("bar")
+	h1.append("baz")
+
+	// Entries from the other process are merged on navigation
+	h2.override("typing")
+	if prev := h2.previous(); prev != "baz" {
+		t.Errorf("Expected: baz, actual: %s", prev)
+	}
+	if prev := h2.previous(); prev != "bar" {
+		t.Errorf("Expected: bar, actual: %s", prev)
+	}
+	h2.override("bar2")
+	h1.append("qux")
+	// The cursor stays on the same entry
+	if prev := h2.previous(); prev != "foo" {
+		t.Errorf("Expected: foo, actual: %s", prev)
+	}
+	if next := h2.next(); next != "bar2" {
+		t.Errorf("Expected: bar2, actual: %s", next)
+	}
+	for _, expected := range []string{"baz", "qux", "typing", "typing"} {
+		if next := h2.next(); next != expected {
+			t.Errorf("Expected: %s, actual: %s", expected, next)
+		}
+	}
+}
+
+func TestHistoryCompaction(t *testing.T) {
+	f, _ := os.CreateTemp("", "fzf-history")
+	f.Close()
+	defer os.Remove(f.Name())
+
+	maxHistory := 10
+	h1, _ := NewHistory(f.Name(), maxHistory)
+	h2, _ := NewHistory(f.Name(), maxHistory)
+	h2.append("first")
+	for i := range maxHistory * historyCompactionFactor * 2 {
+		h1.append(strconv.Itoa(i))
+		data, _ := os.ReadFile(f.Name())
+		if lines := len(parseHistory(data)); lines > maxHistory*historyCompactionFactor {
+			t.Errorf("History file not compacted: %d lines", lines)
+		}
+		if len(h1.lines) > maxHistory+1 {
+			t.Errorf("Too many entries in memory: %d", len(h1.lines))
+		}
+	}
+
+	// Reloaded after compaction
+	last := strconv.Itoa(maxHistory*historyCompactionFactor*2 - 1)
+	if prev := h2.previous(); prev != last {
+		t.Errorf("Expected: %s, actual: %s", last, prev)
+	}
+	if len(h2.lines) != maxHistory+1 {
+		t.Errorf("Expected: %d, actual: %d", maxHistory+1, len(h2.lines))
+	}
+}
+
+func TestHistoryReplaced(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "history")
+	os.WriteFile(path, []byte("foo\n"), 0600)
+
+	maxHistory := 3
+	h, _ := NewHistory(path, maxHistory)
+
+	// The file is replaced by another process compacting it
+	os.WriteFile(path+".new", []byte("bar\n"), 0600)
+	os.Rename(path+".new", path)
+	h.append("baz")
+	if data, _ := os.ReadFile(path); string(data) != "bar\nbaz\n" {
+		t.Errorf("unexpected content: %q", data)
+	}
+
+	// Compaction replaces the file without leaving the temporary file
+	for _, line := range []string{"a", "b", "c", "d", "e"} {
+		h.append(line)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "c\nd\ne\n" {
+		t.Errorf("unexpected content: %q", data)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Errorf("unexpected files: %v", entries)
+	}
+}
+
+func TestHistoryContext(t *testing.T) {
+	f, _ := os.CreateTemp("", "fzf-history")
+	f.WriteString("plain\n")
+	f.Close()
+	defer os.Remove(f.Name())
+
+	h1, _ := NewHistory(f.Name(), 10)
+	h1.setContext("foo")
+	h2, _ := NewHistory(f.Name(), 10)
+	h2.setContext("bar")
+	for _, query := range []string{"a", "b", "a", "c"} {
+		h1.append(query)
+		h2.append(query + query)
+	}
+
+	// Deduplicated and filtered by context
+	if fmt.Sprint(h1.lines) != "[b a c ]" {
+		t.Errorf("unexpected entries: %q", h1.lines)
+	}
+	if fmt.Sprint(h2.lines) != "[bb aa cc ]" {
+		t.Errorf("unexpected entries: %q", h2.lines)
+	}
+
+	// Entries are stored with the timestamp and the directory
+	data, _ := os.ReadFile(f.Name())
+	lines := parseHistory(data)
+	if len(lines) != 9 || lines[0] != "plain" {
+		t.Fatalf("unexpected content: %q", lines)
+	}
+	cwd, _ := os.Getwd()
+	if entry := parseHistoryEntry(lines[1]); entry.Context != "foo" || entry.Query != "a" || entry.Cwd != cwd || entry.Time == 0 {
+		t.Errorf("unexpected entry: %v", entry)
+	}
+
+	// Moved to the front when appended by another process
+	h3, _ := NewHistory(f.Name(), 10)
+	h3.setContext("foo")
+	h3.append("b")
+	if h1.previous() != "b" || h1.previous() != "c" || h1.previous() != "a" || h1.previous() != "a" {
+		t.Errorf("unexpected entries: %q", h1.lines)
+	}
+
+	// Plain history shows every entry
+	h4, _ := NewHistory(f.Name(), 10)
+	if len(h4.lines) != 11 || h4.lines[0] != "plain" || h4.lines[1] != "a" {
+		t.Errorf("unexpected entries: %q", h4.lines)
+	}
+
+	// Compaction removes the duplicate entries
+	compacted := compactHistory(parseHistory([]byte("x\nx\n" + lines[1] + "\n" + lines[2] + "\n" + lines[1] + "\n")))
+	if len(compacted) != 4 || compacted[2] != lines[2] || compacted[3] != lines[1] {
+		t.Errorf("unexpected compaction result: %q", compacted)
+	}
+}
+
+func TestHistorySearch(t *testing.T) {
+	opts := defaultOptions()
+	s := newHistorySearch([]string{"git status", "make test", "git log", "", "git status"}, newHistoryPatternBuilder(opts))
+
+	// Most recent first without duplicates
+	if len(s.matches) != 3 || string(s.matches[0].text) != "git status" || string(s.matches[2].text) != "make test" {
+		t.Errorf("unexpected matches: %v", s.matches)
+	}
+
+	s.query = []rune("gtlg")
+	s.update()
+	if entry, ok := s.current(); !ok || entry != "git log" || len(s.matches) != 1 {
+		t.Errorf("unexpected matches: %v", s.matches)
+	}
+	if fmt.Sprint(s.matches[0].positions) != "[0 2 4 6]" {
+		t.Errorf("unexpected positions: %v", s.matches[0].positions)
+	}
+
+	s.query = []rune("t")
+	s.update()
+	s.move(10)
+	if entry, _ := s.current(); s.cursor != 2 || entry != "make test" {
+		t.Errorf("unexpected entry: %d, %s", s.cursor, entry)
+	}
+
+	s.query = []rune("xyz")
+	s.update()
+	if _, ok := s.current(); ok {
+		t.Error("should not have any match")
+	}
+}
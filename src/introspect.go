@@ -0,0 +1,219 @@
+package fzf
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/junegunn/fzf/src/tui"
+	"github.com/junegunn/fzf/src/util"
+)
+
+// Action names that do not match the names of the action types
+var actionNameOverrides = map[actionType]string{
+	actBackwardDeleteCharEof: "backward-delete-char/eof",
+	actDeleteCharEof:         "delete-char/eof",
+	actBackwardSubWord:       "backward-subword",
+	actForwardSubWord:        "forward-subword",
+	actKillSubWord:           "kill-subword",
+	actBackwardKillSubWord:   "backward-kill-subword",
+	actPosition:              "pos",
+	actChar:                  "put",
+}
+
+// Action types that are not listed, as they cannot be bound by the user or
+// are deprecated
+var internalActions = map[actionType]bool{
+	actIgnore:              true,
+	actExecuteMulti:        true,
+	actStart:               true,
+	actClick:               true,
+	actInvalid:             true,
+	actBracketedPasteBegin: true,
+	actBracketedPasteEnd:   true,
+	actChar:                true,
+	actMouse:               true,
+	actFatal:               true,
+	actSigStop:             true,
+	actAsync:               true,
+}
+
+// Delimiters for the argument of an action, in the order of preference
+var actionArgDelimiters = []string{"()", "[]", "{}", "<>", "~~", "!!", "@@", "##", "$$", "%%", "^^", "&&", "**", ";;", "//", "||"}
+
+// Config is the configuration of the running fzf returned by the server
+type Config struct {
+	Bindings map[string]string `json:"bindings"`
+	Actions  []string          `json:"actions"`
+	Options  ConfigOptions     `json:"options"`
+}
+
+type ConfigOptions struct {
+	Layout        string              `json:"layout"`
+	Multi         int                 `json:"multi"`
+	Nth           string              `json:"nth"`
+	Sort          bool                `json:"sort"`
+	Cycle         bool                `json:"cycle"`
+	Ansi          bool                `json:"ansi"`
+	Prompt        string              `json:"prompt"`
+	PreviewWindow ConfigPreviewWindow `json:"previewWindow"`
+}
+
+type ConfigPreviewWindow struct {
+	Position string `json:"position"`
+	Size     string `json:"size"`
+	Hidden   bool   `json:"hidden"`
+	Wrap     bool   `json:"wrap"`
+	Follow   bool   `json:"follow"`
+	Cycle    bool   `json:"cycle"`
+	Info     bool   `json:"info"`
+}
+
+func actionName(t actionType) string {
+	if name, found := actionNameOverrides[t]; found {
+		return name
+	}
+	return t.Name()
+}
+
+// actionTakesArgument returns true if the action is written with an
+// argument in --bind syntax
+func actionTakesArgument(t actionType) bool {
+	return isExecuteAction(actionName(t)+"(_)") == t
+}
+
+// actionNames returns the names of the actions that can be bound
+func actionNames() []string {
+	names := []string{}
+	for t := actIgnore; t < actionType(len(_actionType_index)-1); t++ {
+		if !internalActions[t] {
+			names = append(names, actionName(t))
+		}
+	}
+	return names
+}
+
+// formatAction renders the action in --bind syntax
+func formatAction(a *action) string {
+	name := actionName(a.t)
+	if a.t == actChar || !actionTakesArgument(a.t) || (a.t == actChangeMulti || a.t == actStartMacro || a.t == actPlayMacro) && len(a.a) == 0 {
+		return name
+	}
+	for _, delim := range actionArgDelimiters {
+		end := delim[1:]
+		if !strings.Contains(a.a, end+"+") && !strings.Contains(a.a, end+",") {
+			return name + delim[:1] + a.a + end
+		}
+	}
+	return name + ":" + a.a
+}
+
+// formatActions renders the list of actions in --bind syntax
+func formatActions(actions []*action) string {
+	strs := make([]string, len(actions))
+	for i, a := range actions {
+		strs[i] = formatAction(a)
+	}
+	return strings.Join(strs, "+")
+}
+
+// eventName returns the name of the event in --bind syntax
+func eventName(e tui.Event) string {
+	switch e.Type {
+	case tui.BackwardEOF:
+		return "backward-eof"
+	case tui.SLeftClick:
+		return "shift-left-click"
+	case tui.SRightClick:
+		return "shift-right-click"
+	case tui.SScrollUp:
+		return "shift-scroll-up"
+	case tui.SScrollDown:
+		return "shift-scroll-down"
+	case tui.Every:
+		return fmt.Sprintf("every(%g)", float64(e.Char)/1000)
+	case tui.Idle:
+		return fmt.Sprintf("idle(%g)", float64(e.Char)/1000)
+	case tui.DebouncedChange:
+		return fmt.Sprintf("change(%g)", float64(e.Char)/1000)
+	}
+	if name := e.KeyName(); len(name) > 0 {
+		return name
+	}
+	return util.ToKebabCase(e.Type.String())
+}
+
+func layoutName(layout layoutType) string {
+	switch layout {
+	case layoutReverse:
+		return "reverse"
+	case layoutReverseList:
+		return "reverse-list"
+	}
+	return "default"
+}
+
+func windowPositionName(position windowPosition) string {
+	switch position {
+	case posUp:
+		return "up"
+	case posDown:
+		return "down"
+	case posLeft:
+		return "left"
+	case posCenter:
+		return "center"
+	case posNext:
+		return "next"
+	}
+	return "right"
+}
+
+// dumpConfig returns the effective key bindings, the list of available
+// actions, and the values of the main options in JSON format
+func (t *Terminal) dumpConfig() string {
+	if !t.tryLock(channelTimeout) {
+		return ""
+	}
+	defer t.mutex.Unlock()
+
+	bindings := make(map[string]string, len(t.keymap))
+	for event, actions := range t.keymap {
+		if len(actions) > 0 {
+			bindings[eventName(event)] = formatActions(actions)
+		}
+	}
+	for sequence, actions := range t.sequences {
+		if len(actions) > 0 {
+			bindings[sequence] = formatActions(actions)
+		}
+	}
+	preview := t.previewOpts
+	if t.activePreviewOpts != nil {
+		preview = *t.activePreviewOpts
+	}
+	config := Config{
+		Bindings: bindings,
+		Actions:  actionNames(),
+		Options: ConfigOptions{
+			Layout: layoutName(t.layout),
+			Multi:  t.multi,
+			Nth:    RangesToString(t.nthCurrent),
+			Sort:   t.sort,
+			Cycle:  t.cycle,
+			Ansi:   t.ansi,
+			Prompt: t.promptString,
+			PreviewWindow: ConfigPreviewWindow{
+				Position: windowPositionName(preview.position),
+				Size:     preview.size.String(),
+				Hidden:   preview.hidden,
+				Wrap:     preview.wrap,
+				Follow:   preview.follow,
+				Cycle:    preview.cycle,
+				Info:     preview.info,
+			},
+		},
+	}
+	bytes, _ := json.Marshal(&config)
+	return string(bytes)
+}
@@ -0,0 +1,105 @@
+package fzf
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/junegunn/fzf/src/tui"
+)
+
+func TestActionNames(t *testing.T) {
+	names := actionNames()
+	for _, name := range []string{"accept", "execute", "backward-delete-char/eof", "pos", "put", "change-preview-window"} {
+		if !slices.Contains(names, name) {
+			t.Errorf("missing action: %s", name)
+		}
+	}
+	for _, name := range []string{"start", "char", "async", "ignore", "execute-multi"} {
+		if slices.Contains(names, name) {
+			t.Errorf("internal action should not be listed: %s", name)
+		}
+	}
+
+	// Every listed action should be valid in --bind
+	for _, name := range names {
+		spec := name
+		switch name {
+		case "unbind", "rebind", "toggle-bind":
+			spec += "(ctrl-a)"
+		case "change-preview-window":
+			spec += "(up)"
+		default:
+			if isExecuteAction(name+"(_)") != actIgnore {
+				spec += "(1)"
+			}
+		}
+		if _, err := parseSingleActionList(spec, true); err != nil {
+			t.Errorf("invalid action: %s (%v)", spec, err)
+		}
+	}
+}
+
+func TestFormatActions(t *testing.T) {
+	for _, str := range []string{
+		"accept",
+		"up+down",
+		"delete-char/eof+backward-kill-subword",
+		"execute(echo {})+change-query(foo)",
+		"become[echo (foo)+bar]",
+		"change-preview-window(up,50%|hidden)+change-multi+change-multi(3)",
+		"pos(-1)+put(x)+unbind(ctrl-a,ctrl-b)",
+	} {
+		actions, err := parseSingleActionList(str, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		formatted := formatActions(actions)
+		if formatted != str {
+			t.Errorf("expected: %s, actual: %s", str, formatted)
+		}
+	}
+
+	// Argument containing the closing parenthesis followed by +
+	actions := []*action{{t: actExecute, a: "echo (foo)+(bar)"}, {t: actUp}}
+	formatted := formatActions(actions)
+	parsed, err := parseSingleActionList(formatted, true)
+	if err != nil || len(parsed) != 2 || parsed[0].a != actions[0].a || parsed[1].t != actUp {
+		t.Errorf("failed to parse formatted actions: %s", formatted)
+	}
+}
+
+func TestEventName(t *testing.T) {
+	events := []tui.Event{tui.Key('a'), tui.Key(' '), tui.AltKey('x'), tui.CtrlAltKey('b'), {Type: tui.Every, Char: 1500}, {Type: tui.Idle, Char: 500}, {Type: tui.DebouncedChange, Char: 300}}
+	for e := tui.CtrlA; e <= tui.ResultFinal; e++ {
+		switch e {
+		case tui.CtrlH, tui.Alt, tui.CtrlAlt, tui.Mouse, tui.Invalid, tui.Fatal, tui.BracketedPasteBegin, tui.BracketedPasteEnd, tui.Every, tui.Idle, tui.DebouncedChange:
+			continue
+		}
+		events = append(events, e.AsEvent())
+	}
+	for _, event := range events {
+		name := eventName(event)
+		keys, _, err := parseKeyChords(name, "key name required")
+		if err != nil || len(keys) != 1 || firstKey(keys) != event {
+			t.Errorf("failed to parse %s: %v", name, err)
+		}
+	}
+	if name := eventName(tui.Event{Type: tui.Every, Char: 1500}); name != "every(1.5)" {
+		t.Errorf("unexpected name: %s", name)
+	}
+}
+
+func TestConfigEndpoint(t *testing.T) {
+	listener, port := startTestServerWithHandlers(t, make(chan serverRequest), func(getParams) string {
+		return "{}"
+	}, nil, nil, func() string {
+		return `{"bindings":{}}`
+	})
+	defer listener.Close()
+
+	response := httpRequest(t, port, "GET /config HTTP/1.1\r\nConnection: close\r\n\r\n")
+	if !strings.HasPrefix(response, httpOk) || !strings.HasSuffix(response, `{"bindings":{}}`+"\n") {
+		t.Errorf("unexpected response: %q", response)
+	}
+}
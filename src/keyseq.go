@@ -0,0 +1,138 @@
+package fzf
+
+import (
+	"errors"
+	"slices"
+	"strings"
+
+	"github.com/junegunn/fzf/src/tui"
+)
+
+// isKeySequence returns true if the key name is a sequence of keys separated
+// by spaces, e.g. "ctrl-x ctrl-f" or "space g s"
+func isKeySequence(str string) bool {
+	return len(strings.Fields(str)) > 1
+}
+
+// parseKeySequence parses the sequence of keys and returns its canonical name
+func parseKeySequence(str string) (string, error) {
+	names := []string{}
+	for _, field := range strings.Fields(str) {
+		keys, _, err := parseKeyChords(field, "key name required")
+		if err != nil {
+			return "", err
+		}
+		if len(keys) != 1 {
+			return "", errors.New("invalid key sequence: " + str)
+		}
+		key := firstKey(keys)
+		if key.Type >= tui.Invalid {
+			return "", errors.New("only keys are allowed in key sequence: " + str)
+		}
+		names = append(names, eventName(key))
+	}
+	return strings.Join(names, " "), nil
+}
+
+func keySequenceName(keys []tui.Event) string {
+	names := make([]string, len(keys))
+	for i, key := range keys {
+		names[i] = eventName(key)
+	}
+	return strings.Join(names, " ")
+}
+
+// parseBindTargets parses the argument of unbind, rebind, and toggle-bind
+// actions, which can contain key sequences
+func parseBindTargets(str string, message string) (map[tui.Event]string, []string, error) {
+	if !strings.ContainsAny(str, " \t") {
+		keys, _, err := parseKeyChords(str, message)
+		return keys, nil, err
+	}
+	chords := []string{}
+	sequences := []string{}
+	for _, token := range strings.Split(str, ",") {
+		if isKeySequence(token) {
+			name, err := parseKeySequence(token)
+			if err != nil {
+				return nil, nil, err
+			}
+			sequences = append(sequences, name)
+		} else if token = strings.TrimSpace(token); len(token) > 0 {
+			chords = append(chords, token)
+		}
+	}
+	keys := make(map[tui.Event]string)
+	if len(chords) > 0 {
+		var err error
+		if keys, _, err = parseKeyChords(strings.Join(chords, ","), message); err != nil {
+			return nil, nil, err
+		}
+	}
+	return keys, sequences, nil
+}
+
+// extendKeySequence handles the key pressed while a key sequence may be
+// pending. It returns true if the key is consumed as a part of a sequence,
+// along with the actions to perform. A sequence can only be started when the
+// query is not being typed, i.e. when the input section is hidden or in the
+// normal mode of vi.
+func (t *Terminal) extendKeySequence(event tui.Event) (bool, []*action) {
+	if len(t.sequences) == 0 || event.Type >= tui.Invalid {
+		return false, nil
+	}
+	if len(t.pendingKeys) == 0 && !t.inputless && t.viMode() != viNormal {
+		return false, nil
+	}
+	keys := append(t.pendingKeys, event.Comparable())
+	name := keySequenceName(keys)
+	for sequence := range t.sequences {
+		if strings.HasPrefix(sequence, name+" ") {
+			// Wait for the next key
+			t.pendingKeys = keys
+			return true, nil
+		}
+	}
+	if actions, found := t.sequences[name]; found {
+		t.pendingKeys = nil
+		return true, actions
+	}
+	if len(t.pendingKeys) == 0 {
+		return false, nil
+	}
+
+	// The key can start a new sequence
+	flushed := t.flushKeySequence()
+	consumed, actions := t.extendKeySequence(event)
+	return consumed, slices.Concat(flushed, actions)
+}
+
+// flushKeySequence clears the pending keys and returns the actions to perform
+// for them. If the pending keys are bound as a sequence, the actions for the
+// sequence are returned. Otherwise, the actions for the individual keys are.
+// In the normal mode of vi, the keys are handled as vi commands first.
+func (t *Terminal) flushKeySequence() []*action {
+	keys := t.pendingKeys
+	t.pendingKeys = nil
+	if len(keys) == 0 {
+		return nil
+	}
+	if actions, found := t.sequences[keySequenceName(keys)]; found {
+		return actions
+	}
+	actions := []*action{}
+	for _, key := range keys {
+		if t.viMode() == viNormal {
+			if consumed, acts := t.viKeyWithUndo(key); consumed {
+				actions = append(actions, acts...)
+				continue
+			}
+		}
+		if acts, found := t.keymap[key]; found {
+			actions = append(actions, acts...)
+		} else if key.Type == tui.Rune {
+			actions = append(actions, &action{t: actPut, a: string(key.Char)})
+		}
+	}
+	return actions
+}
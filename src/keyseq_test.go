@@ -0,0 +1,148 @@
+package fzf
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/junegunn/fzf/src/tui"
+)
+
+func TestParseKeySequence(t *testing.T) {
+	for str, expected := range map[string]string{
+		"ctrl-x ctrl-f": "ctrl-x ctrl-f",
+		"space  g s":    "space g s",
+		"CTRL-X A":      "ctrl-x A",
+		"alt-a enter":   "alt-a enter",
+	} {
+		if name, err := parseKeySequence(str); err != nil || name != expected {
+			t.Errorf("expected: %s, actual: %s (%v)", expected, name, err)
+		}
+	}
+	for _, str := range []string{"ctrl-x foo", "ctrl-x start", "ctrl-x change"} {
+		if _, err := parseKeySequence(str); err == nil {
+			t.Errorf("should fail: %s", str)
+		}
+	}
+}
+
+func TestParseKeymapSequences(t *testing.T) {
+	keymap := make(map[tui.Event][]*action)
+	sequences := make(map[string][]*action)
+	if err := parseKeymap(keymap, sequences, "ctrl-x ctrl-f:first+accept,space g s:unbind(ctrl-x ctrl-f,ctrl-a),ctrl-x:last"); err != nil {
+		t.Fatal(err)
+	}
+	if actions := sequences["ctrl-x ctrl-f"]; len(actions) != 2 || actions[0].t != actFirst || actions[1].t != actAccept {
+		t.Errorf("unexpected actions: %v", actions)
+	}
+	if actions := sequences["space g s"]; len(actions) != 1 || actions[0].t != actUnbind {
+		t.Errorf("unexpected actions: %v", actions)
+	}
+	if actions := keymap[tui.CtrlX.AsEvent()]; len(actions) != 1 || actions[0].t != actLast {
+		t.Errorf("unexpected actions: %v", actions)
+	}
+	if err := parseKeymap(keymap, nil, "ctrl-x ctrl-f:accept"); err == nil {
+		t.Error("key sequence should not be allowed")
+	}
+	if err := parseKeymap(keymap, sequences, "ctrl-x foo:accept"); err == nil {
+		t.Error("should fail with invalid key")
+	}
+}
+
+func TestParseBindTargets(t *testing.T) {
+	keys, sequences, err := parseBindTargets("ctrl-a,ctrl-x ctrl-f, space g s ,alt-b", "")
+	if err != nil || len(keys) != 2 || len(sequences) != 2 || sequences[0] != "ctrl-x ctrl-f" || sequences[1] != "space g s" {
+		t.Errorf("unexpected result: %v, %v, %v", keys, sequences, err)
+	}
+	if _, found := keys[tui.CtrlA.AsEvent()]; !found {
+		t.Errorf("ctrl-a not found: %v", keys)
+	}
+	if keys, sequences, err := parseBindTargets("alt-,,ctrl-a", ""); err != nil || len(keys) != 2 || len(sequences) != 0 {
+		t.Errorf("unexpected result: %v, %v, %v", keys, sequences, err)
+	}
+}
+
+func TestExtendKeySequence(t *testing.T) {
+	term := Terminal{
+		inputless: true,
+		keymap:    map[tui.Event][]*action{tui.CtrlX.AsEvent(): toActions(actLast)},
+		sequences: map[string][]*action{
+			"ctrl-x ctrl-f": toActions(actFirst),
+			"space g":       toActions(actUp),
+			"space g s":     toActions(actDown),
+		},
+	}
+
+	// Complete sequence
+	if consumed, actions := term.extendKeySequence(tui.CtrlX.AsEvent()); !consumed || actions != nil || len(term.pendingKeys) != 1 {
+		t.Errorf("should be pending: %v", term.pendingKeys)
+	}
+	if consumed, actions := term.extendKeySequence(tui.CtrlF.AsEvent()); !consumed || len(actions) != 1 || actions[0].t != actFirst || term.pendingKeys != nil {
+		t.Errorf("unexpected actions: %v", actions)
+	}
+
+	// Abandoned sequence
+	term.extendKeySequence(tui.CtrlX.AsEvent())
+	if consumed, actions := term.extendKeySequence(tui.CtrlA.AsEvent()); consumed || len(actions) != 1 || actions[0].t != actLast {
+		t.Errorf("unexpected actions: %v", actions)
+	}
+	term.extendKeySequence(tui.Key(' '))
+	if consumed, actions := term.extendKeySequence(tui.Key('x')); consumed || len(actions) != 1 || actions[0].t != actPut || actions[0].a != " " {
+		t.Errorf("unexpected actions: %v", actions)
+	}
+
+	// Timeout on a sequence that is a prefix of a longer one
+	term.extendKeySequence(tui.Key(' '))
+	term.extendKeySequence(tui.Key('g'))
+	if actions := term.flushKeySequence(); len(actions) != 1 || actions[0].t != actUp || term.pendingKeys != nil {
+		t.Errorf("unexpected actions: %v", actions)
+	}
+
+	// Key that abandons a sequence starts a new one
+	term.extendKeySequence(tui.CtrlX.AsEvent())
+	if consumed, actions := term.extendKeySequence(tui.Key(' ')); !consumed || len(actions) != 1 || actions[0].t != actLast || len(term.pendingKeys) != 1 {
+		t.Errorf("unexpected actions: %v", actions)
+	}
+	term.extendKeySequence(tui.Key('g'))
+	if consumed, actions := term.extendKeySequence(tui.Key('s')); !consumed || len(actions) != 1 || actions[0].t != actDown {
+		t.Errorf("unexpected actions: %v", actions)
+	}
+
+	// Key not a part of any sequence
+	if consumed, actions := term.extendKeySequence(tui.Key('a')); consumed || actions != nil {
+		t.Errorf("unexpected actions: %v", actions)
+	}
+
+	// Sequences are not started while typing the query
+	term.inputless = false
+	if consumed, actions := term.extendKeySequence(tui.CtrlX.AsEvent()); consumed || actions != nil || term.pendingKeys != nil {
+		t.Errorf("unexpected actions: %v", actions)
+	}
+	term.vi = &viState{mode: viNormal}
+	if consumed, _ := term.extendKeySequence(tui.CtrlX.AsEvent()); !consumed || len(term.pendingKeys) != 1 {
+		t.Errorf("should be pending in vi normal mode: %v", term.pendingKeys)
+	}
+}
+
+func TestScreenKeySequenceViNormalMode(t *testing.T) {
+	st := startScreenTest(t, 20, 5, []string{"foo"}, "--reverse", "--vi-mode", "--query", "foobar",
+		"--sequence-timeout", "100", "--bind", "x y:first")
+	prompt := func(query string) {
+		t.Helper()
+		st.until(func(screen string) bool { return strings.TrimSpace(strings.Split(screen, "\n")[0]) == "> "+query })
+	}
+	prompt("foobar")
+	st.send(tui.Esc.AsEvent())
+
+	// The pending key is handled as a vi command on timeout
+	st.send(tui.Key('x'))
+	prompt("fooba")
+
+	// The key that breaks the sequence is a vi command, and it can start
+	// a new sequence
+	st.typeString("0xx")
+	prompt("oba")
+	st.send(tui.Key('x'), tui.Key('l'))
+	prompt("ba")
+	st.send(tui.CtrlC.AsEvent())
+	st.wait()
+}
@@ -0,0 +1,141 @@
+package fzf
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+)
+
+// Macros holds the sequences of actions recorded with start-macro. If the
+// path is given, the macros are loaded from the file and the file is
+// rewritten when a recording is finished.
+//
+// Each line of the file is a macro in NAME:ACTIONS format, where ACTIONS is
+// in --bind syntax. The name of the unnamed macro is empty.
+//
+//	:select-all+deselect+first
+//	edit:put(foo)+execute(vim {})
+type Macros struct {
+	path   string
+	macros map[string][]*action
+}
+
+// NewMacros returns the pointer to a new Macros struct. If the path is not
+// empty, the macros are loaded from the file. The file is not created until
+// a macro is recorded.
+func NewMacros(path string) (*Macros, error) {
+	m := &Macros{path: path, macros: make(map[string][]*action)}
+	if len(path) == 0 {
+		return m, nil
+	}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return m, nil
+	} else if err != nil {
+		return nil, fmtMacroError(path, err)
+	}
+	if m.macros, err = parseMacros(string(data)); err != nil {
+		return nil, fmtMacroError(path, err)
+	}
+	return m, nil
+}
+
+func fmtMacroError(path string, err error) error {
+	if os.IsPermission(err) {
+		return errors.New("permission denied: " + path)
+	}
+	return errors.New("invalid macro file: " + err.Error())
+}
+
+func validateMacroName(name string) error {
+	if strings.ContainsAny(name, ":\n") {
+		return errors.New("invalid macro name: " + name)
+	}
+	return nil
+}
+
+func parseMacros(data string) (map[string][]*action, error) {
+	macros := make(map[string][]*action)
+	for lineNum, line := range strings.Split(data, "\n") {
+		if len(strings.TrimSpace(line)) == 0 {
+			continue
+		}
+		name, str, found := strings.Cut(line, ":")
+		if !found {
+			return nil, fmt.Errorf("line %d: actions not specified", lineNum+1)
+		}
+		actions, err := parseSingleActionList(str, true)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNum+1, err)
+		}
+		macros[name] = actions
+	}
+	return macros, nil
+}
+
+func (m *Macros) get(name string) ([]*action, bool) {
+	actions, found := m.macros[name]
+	return actions, found
+}
+
+// set stores the macro and writes the macros to the file
+func (m *Macros) set(name string, actions []*action) error {
+	m.macros[name] = actions
+	if len(m.path) == 0 {
+		return nil
+	}
+	return os.WriteFile(m.path, []byte(m.String()), 0600)
+}
+
+// String returns the macros in the format of the macro file
+func (m *Macros) String() string {
+	names := make([]string, 0, len(m.macros))
+	for name, actions := range m.macros {
+		if len(actions) > 0 {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+	var builder strings.Builder
+	for _, name := range names {
+		builder.WriteString(name + ":" + formatActions(m.macros[name]) + "\n")
+	}
+	return builder.String()
+}
+
+// macroAction returns true if the action records or plays a macro. As a macro
+// can start processes, these actions are not allowed from the remote clients
+// of the server that can't start processes.
+func macroAction(action actionType) bool {
+	return action == actStartMacro || action == actPlayMacro
+}
+
+// macroRecording is the macro being recorded
+type macroRecording struct {
+	name    string
+	actions []*action
+}
+
+// record appends the actions triggered by the key. The actions after
+// stop-macro are not recorded, and the character inserted by the key is
+// recorded as 'put' action so that it can be replayed with any key.
+func (r *macroRecording) record(actions []*action, char rune) {
+	for _, a := range actions {
+		switch a.t {
+		case actStartMacro:
+			continue
+		case actStopMacro:
+			return
+		case actChar:
+			a = &action{t: actPut, a: string(char)}
+		}
+		// Merge consecutive insertions
+		if last := len(r.actions) - 1; a.t == actPut && last >= 0 && r.actions[last].t == actPut {
+			r.actions[last] = &action{t: actPut, a: r.actions[last].a + a.a}
+			continue
+		}
+		r.actions = append(r.actions, a)
+	}
+}
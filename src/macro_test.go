@@ -0,0 +1,127 @@
+package fzf
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/junegunn/fzf/src/tui"
+)
+
+func TestMacroRecording(t *testing.T) {
+	r := macroRecording{}
+	r.record([]*action{{t: actStartMacro}}, 0)
+	r.record([]*action{{t: actChar}}, 'f')
+	r.record([]*action{{t: actChar}}, 'o')
+	r.record([]*action{{t: actPut, a: "o"}}, 'x')
+	r.record([]*action{{t: actSelectAll}, {t: actPlayMacro, a: "foo"}}, 0)
+	r.record([]*action{{t: actChar}}, ')')
+	r.record([]*action{{t: actFirst}, {t: actStopMacro}, {t: actLast}}, 0)
+	str := formatActions(r.actions)
+	if str != "put(foo)+select-all+play-macro(foo)+put())+first" {
+		t.Errorf("unexpected actions: %s", str)
+	}
+	if actions, err := parseSingleActionList(str, true); err != nil || formatActions(actions) != str {
+		t.Errorf("failed to parse the recorded actions: %v", err)
+	}
+}
+
+func TestMacroActions(t *testing.T) {
+	for _, action := range []actionType{actStartMacro, actPlayMacro} {
+		if safeAction(action) {
+			t.Errorf("%s should not be allowed with a safe key", action.Name())
+		}
+		if processExecution(action) {
+			t.Errorf("%s should not be treated as process execution", action.Name())
+		}
+	}
+	if !safeAction(actStopMacro) {
+		t.Error("stop-macro should be allowed with a safe key")
+	}
+}
+
+func TestParseMacros(t *testing.T) {
+	macros, err := parseMacros(":select-all+deselect\n\nfoo:put(bar)+execute(echo a:b)+play-macro\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(macros) != 2 || formatActions(macros[""]) != "select-all+deselect" ||
+		formatActions(macros["foo"]) != "put(bar)+execute(echo a:b)+play-macro" {
+		t.Errorf("unexpected macros: %v", macros)
+	}
+
+	for _, str := range []string{"foo", "foo:bar", ":start-macro(a:b)"} {
+		if _, err := parseMacros(str); err == nil {
+			t.Errorf("should fail to parse: %s", str)
+		}
+	}
+}
+
+func TestMacroFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "macros")
+	m, err := NewMacros(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Error("macro file should not be created until a macro is recorded")
+	}
+	if err := m.set("foo", []*action{{t: actPut, a: "foo"}, {t: actFirst}}); err != nil {
+		t.Error(err)
+	}
+	m.set("", []*action{{t: actToggle}})
+	m.set("bar", nil)
+	if data, _ := os.ReadFile(path); string(data) != ":toggle\nfoo:put(foo)+first\n" {
+		t.Errorf("unexpected content: %q", string(data))
+	}
+
+	m, err = NewMacros(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if actions, found := m.get("foo"); !found || formatActions(actions) != "put(foo)+first" {
+		t.Errorf("macro not loaded: %v", actions)
+	}
+
+	// Failed to write the file
+	m, _ = NewMacros(filepath.Join(t.TempDir(), "missing", "macros"))
+	if err := m.set("foo", []*action{{t: actFirst}}); err == nil {
+		t.Error("should fail to write the file")
+	}
+	if _, found := m.get("foo"); !found {
+		t.Error("macro should be kept in memory")
+	}
+}
+
+func TestPlayMacro(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "macros")
+	os.WriteFile(path, []byte("loop:put(x)+play-macro(loop)\n"), 0600)
+	st := startScreenTest(t, 20, 5, []string{"foo", "bar", "baz"}, "--reverse", "--macro-file", path,
+		"--bind", "f1:start-macro(q),f2:stop-macro,f3:play-macro(q),f4:clear-query,f5:play-macro(loop)")
+	st.send(tui.F1.AsEvent())
+	st.typeString("ba")
+	st.send(tui.Down.AsEvent(), tui.LeftClick.AsEvent(), tui.F2.AsEvent(), tui.F4.AsEvent())
+	st.expect(`
+>
+  3/3 ─────────────
+▌ foo
+▌ bar
+▌ baz`)
+	st.send(tui.F3.AsEvent())
+	st.expect(`
+> ba
+  2/3 ─────────────
+▌ bar
+▌ baz`)
+	if data, _ := os.ReadFile(path); string(data) != "loop:put(x)+play-macro(loop)\nq:put(ba)+down\n" {
+		t.Errorf("unexpected content: %q", string(data))
+	}
+
+	// Recursive playback is ignored
+	st.send(tui.F4.AsEvent(), tui.F5.AsEvent())
+	st.expect(`
+> x
+  0/3 ─────────────`)
+	st.send(tui.Esc.AsEvent())
+	st.wait()
+}
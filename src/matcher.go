@@ -17,12 +17,14 @@ type MatchRequest struct {
 	final    bool
 	sort     bool
 	revision revision
+	seq      int64
 }
 
 type MatchResult struct {
 	merger     *Merger
 	passMerger *Merger
 	cancelled  bool
+	seq        int64
 }
 
 func (mr MatchResult) cacheable() bool {
@@ -144,6 +146,7 @@ func (m *Matcher) Loop() {
 				m.mergerCache[patternString] = result
 			}
 			result.merger.final = request.final
+			result.seq = request.seq
 			m.eventBox.Set(EvtSearchFin, result)
 		}
 	}
@@ -160,12 +163,12 @@ func (m *Matcher) scan(request MatchRequest) MatchResult {
 	numChunks := len(request.chunks)
 	if numChunks == 0 {
 		m := EmptyMerger(request.revision)
-		return MatchResult{m, m, false}
+		return MatchResult{m, m, false, 0}
 	}
 	pattern := request.pattern
 	passMerger := PassMerger(&request.chunks, m.tac, request.revision, pattern.startIndex)
 	if pattern.IsEmpty() {
-		return MatchResult{passMerger, passMerger, false}
+		return MatchResult{passMerger, passMerger, false, 0}
 	}
 
 	minIndex := request.chunks[0].items[0].Index()
@@ -222,7 +225,7 @@ func (m *Matcher) scan(request MatchRequest) MatchResult {
 		}
 
 		if m.cancelScan.Get() || m.reqBox.Peek(reqReset) {
-			return MatchResult{nil, nil, wait()}
+			return MatchResult{nil, nil, wait(), 0}
 		}
 
 		if time.Since(startedAt) > progressMinDuration {
@@ -236,11 +239,59 @@ func (m *Matcher) scan(request MatchRequest) MatchResult {
 		partialResults[partialResult.index] = partialResult.matches
 	}
 	merger := NewMerger(pattern, partialResults, m.sort && request.pattern.sortable, m.tac, request.revision, minIndex, maxIndex)
-	return MatchResult{merger, passMerger, false}
+	return MatchResult{merger, passMerger, false, 0}
 }
 
-// Reset is called to interrupt/signal the ongoing search
-func (m *Matcher) Reset(chunks []*Chunk, patternRunes []rune, cancel bool, final bool, sort bool, revision revision) {
+// Search performs a one-off search independent of the interactive search. It
+// uses its own buffers and reports no progress, so it can run concurrently
+// with the main loop without affecting it. Returns nil if cancelled.
+func (m *Matcher) Search(chunks []*Chunk, pattern *Pattern, sort bool, cancelled *util.AtomicBool) *Merger {
+	numChunks := len(chunks)
+	if numChunks == 0 {
+		return EmptyMerger(revision{})
+	}
+	if pattern.IsEmpty() {
+		return PassMerger(&chunks, m.tac, revision{}, pattern.startIndex)
+	}
+
+	minIndex := chunks[0].items[0].Index()
+	maxIndex := chunks[numChunks-1].lastIndex(minIndex)
+	sort = sort && pattern.sortable
+
+	numWorkers := min(m.partitions, numChunks)
+	partialResults := make([][]Result, numWorkers)
+	var nextChunk atomic.Int32
+	waitGroup := sync.WaitGroup{}
+	for idx := range numWorkers {
+		waitGroup.Add(1)
+		go func(idx int) {
+			defer waitGroup.Done()
+			slab := util.MakeSlab(slab16Size, slab32Size)
+			var matches []Result
+			for {
+				ci := int(nextChunk.Add(1)) - 1
+				if ci >= numChunks || cancelled.Get() {
+					break
+				}
+				matches = append(matches, pattern.Match(chunks[ci], slab)...)
+			}
+			if sort && !cancelled.Get() {
+				radixSortResults(matches, m.tac, nil)
+			}
+			partialResults[idx] = matches
+		}(idx)
+	}
+	waitGroup.Wait()
+	if cancelled.Get() {
+		return nil
+	}
+	return NewMerger(pattern, partialResults, sort, m.tac, revision{}, minIndex, maxIndex)
+}
+
+// Reset is called to interrupt/signal the ongoing search. seq is the sequence
+// number of the last search request from the terminal, which is passed back
+// with the result.
+func (m *Matcher) Reset(chunks []*Chunk, patternRunes []rune, cancel bool, final bool, sort bool, revision revision, seq int64) {
 	pattern := m.patternBuilder(patternRunes)
 
 	var event util.EventType
@@ -249,7 +300,7 @@ func (m *Matcher) Reset(chunks []*Chunk, patternRunes []rune, cancel bool, final
 	} else {
 		event = reqRetry
 	}
-	m.reqBox.Set(event, MatchRequest{chunks, pattern, final, sort, revision})
+	m.reqBox.Set(event, MatchRequest{chunks, pattern, final, sort, revision, seq})
 }
 
 // CancelScan cancels any in-flight scan, waits for it to finish,
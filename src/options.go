@@ -48,13 +48,26 @@ Usage: fzf [options]
     +s, --no-sort            Do not sort the result
     --literal                Do not normalize latin script letters
     --tail=NUM               Maximum number of items to keep in memory
+    --dedup[=N[,..]]         Drop duplicate lines, optionally comparing only
+                             the given fields
+    --dedup-keep=first|last  Which occurrence of duplicate lines to keep
+                             (default: first)
     --disabled               Do not perform search
     --tiebreak=CRI[,..]      Comma-separated list of sort criteria to apply
                              when the scores are tied
-                             [length|chunk|pathname|begin|end|index] (default: length)
+                             [length|chunk|pathname|begin|end|source|index]
+                             (default: length)
 
   INPUT/OUTPUT
     --read0                  Read input delimited by ASCII NUL characters
+    --csv                    Read input as comma-separated values with RFC 4180
+                             quoting (implies --delimiter=,)
+    --tsv                    Read input as tab-separated values with RFC 4180
+                             quoting (implies --delimiter=\t)
+    --input-file=PATH        Read input from the memory-mapped file
+    --input-decompress       Decompress gzip, bzip2, and zstd input on the fly
+    --source=NAME[:PRI]=CMD  Read the output of CMD as a named input source.
+                             Repeat to read multiple sources concurrently.
     --print0                 Print output delimited by ASCII NUL characters
     --ansi                   Enable processing of ANSI color codes
     --sync                   Synchronous search for multi-staged filtering
@@ -100,6 +113,7 @@ Usage: fzf [options]
     --wrap[=MODE]            Enable line wrap (char|word, default: char)
     --wrap-sign=STR          Indicator for wrapped lines
     --no-multi-line          Disable multi-line display of items when using --read0
+                             or --csv
     --raw                    Enable raw mode (show non-matching items)
     --track                  Track the current selection when the result is updated
     --id-nth=N[,..]          Define item identity fields for cross-reload operations
@@ -147,6 +161,7 @@ Usage: fzf [options]
     --no-separator           Hide info line separator
     --ghost=TEXT             Ghost text to display when the input is empty
     --filepath-word          Make word-wise movements respect path separators
+    --vi-mode                Enable vi-style modal editing of the query
     --input-border[=STYLE]   Draw border around the input section
                              [rounded|sharp|bold|block|thinblock|double|dashed|horizontal|vertical|
                               top|bottom|left|right|line|none] (default: rounded)
@@ -210,6 +225,9 @@ Usage: fzf [options]
 
   KEY/EVENT BINDING
     --bind=BINDINGS          Custom key/event bindings
+    --sequence-timeout=MS    Time to wait for the next key of a key sequence
+                             (default: 1000)
+    --macro-file=FILE        File to store the macros recorded with start-macro
 
   ADVANCED
     --with-shell=STR         Shell command and flags to start child processes with
@@ -217,6 +235,15 @@ Usage: fzf [options]
                              (To allow remote process execution, use --listen-unsafe)
     --listen=SOCKET_PATH     Start HTTP server to receive actions via Unix domain socket
                              (Path should end with .sock)
+    --listen-tls[=CERT,KEY]  Serve --listen over TLS with the certificate and key
+                             files (Self-signed certificate if not given;
+                             required for a non-localhost address)
+    --remote[=ADDR] ACTIONS  Send actions to the server of fzf started with --listen
+                             (Prints the state in JSON without actions;
+                             default address: $FZF_SOCK or $FZF_PORT)
+    --record=FILE            Record the input and the key events to the file
+    --replay=FILE            Replay the session recorded with --record without
+                             the terminal and print the output
 
   DIRECTORY TRAVERSAL        (Only used when $FZF_DEFAULT_COMMAND is not set)
     --walker=OPTS            [file][,dir][,follow][,hidden] (default: file,follow,hidden)
@@ -227,6 +254,8 @@ Usage: fzf [options]
   HISTORY
     --history=FILE           File to store fzf search history (*not* shell command history)
     --history-size=N         Maximum number of entries to keep in the file (default: 1000)
+    --history-context=KEY    Store entries with timestamp and directory, and only
+                             navigate the entries with the same context key
 
   SHELL INTEGRATION
     --bash                   Print script to set up Bash shell integration
@@ -244,6 +273,7 @@ Usage: fzf [options]
     FZF_DEFAULT_OPTS         Default options (e.g. '--layout=reverse --info=inline')
     FZF_DEFAULT_OPTS_FILE    Location of the file to read default options from
     FZF_API_KEY              X-API-Key header for HTTP server (--listen)
+    FZF_API_KEYS             Additional API keys with scopes (SCOPE:KEY,...)
 
 `
 
@@ -272,6 +302,7 @@ const (
 	byBegin
 	byEnd
 	byPathname
+	bySource
 )
 
 type heightSpec struct {
@@ -578,6 +609,12 @@ type walkerOpts struct {
 // Options stores the values of command-line options
 type Options struct {
 	Input             chan string
+	Sources           []inputSource
+	InputFile         string
+	InputDecompress   bool
+	Dedup             bool
+	DedupNth          []Range
+	DedupLast         bool
 	Output            chan string
 	NoWinpty          bool
 	Tmux              *tmuxOptions
@@ -599,9 +636,9 @@ type Options struct {
 	Nth               []Range
 	FreezeLeft        int
 	FreezeRight       int
-	WithNth           func(Delimiter) func([]Token, int32) string
+	WithNth           func(Delimiter, func(int32) string) func([]Token, int32) string
 	WithNthExpr       string
-	AcceptNth         func(Delimiter) func([]Token, int32) string
+	AcceptNth         func(Delimiter, func(int32) string) func([]Token, int32) string
 	Delimiter         Delimiter
 	Sort              int
 	Raw               bool
@@ -651,6 +688,10 @@ type Options struct {
 	ToggleSort        bool
 	Expect            map[tui.Event]string
 	Keymap            map[tui.Event][]*action
+	Sequences         map[string][]*action
+	ViMode            bool
+	SequenceTimeout   time.Duration
+	Macros            *Macros
 	Preview           previewOpts
 	PrintQuery        bool
 	ReadZero          bool
@@ -685,7 +726,13 @@ type Options struct {
 	Tabstop           int
 	WithShell         string
 	ListenAddr        *listenAddress
+	ListenTLS         *tlsOpts
+	Record            string
+	Replay            string
+	renderer          tui.Renderer // Renderer to use instead of the terminal (tests only)
 	Unsafe            bool
+	Remote            *listenAddress
+	RemoteActions     []string
 	ClearOnExit       bool
 	WalkerOpts        walkerOpts
 	WalkerRoot        []string
@@ -785,6 +832,7 @@ func defaultOptions() *Options {
 		ToggleSort:   false,
 		Expect:       make(map[tui.Event]string),
 		Keymap:       make(map[tui.Event][]*action),
+		Sequences:    make(map[string][]*action),
 		Preview:      defaultPreviewOpts(""),
 		PrintQuery:   false,
 		ReadZero:     false,
@@ -854,14 +902,14 @@ func splitNth(str string) ([]Range, error) {
 	return ranges, nil
 }
 
-func nthTransformer(str string) (func(Delimiter) func([]Token, int32) string, error) {
+func nthTransformer(str string) (func(Delimiter, func(int32) string) func([]Token, int32) string, error) {
 	// ^[0-9,-.]+$"
 	if match, _ := regexp.MatchString("^[0-9,-.]+$", str); match {
 		nth, err := splitNth(str)
 		if err != nil {
 			return nil, err
 		}
-		return func(Delimiter) func([]Token, int32) string {
+		return func(Delimiter, func(int32) string) func([]Token, int32) string {
 			return func(tokens []Token, index int32) string {
 				return JoinTokens(Transform(tokens, nth))
 			}
@@ -869,16 +917,17 @@ func nthTransformer(str string) (func(Delimiter) func([]Token, int32) string, er
 	}
 
 	// {...} {...} ...
-	placeholder := regexp.MustCompile("{[0-9,-.]+}|{n}")
+	placeholder := regexp.MustCompile("{[0-9,-.]+}|{n}|{src}")
 	indexes := placeholder.FindAllStringIndex(str, -1)
 	if indexes == nil {
 		return nil, errors.New("template should include at least 1 placeholder: " + str)
 	}
 
 	type NthParts struct {
-		str   string
-		index bool
-		nth   []Range
+		str    string
+		index  bool
+		source bool
+		nth    []Range
 	}
 
 	parts := make([]NthParts, 0, len(indexes))
@@ -890,6 +939,8 @@ func nthTransformer(str string) (func(Delimiter) func([]Token, int32) string, er
 		expr := str[index[0]+1 : index[1]-1]
 		if expr == "n" {
 			parts = append(parts, NthParts{index: true})
+		} else if expr == "src" {
+			parts = append(parts, NthParts{source: true})
 		} else if nth, err := splitNth(expr); err == nil {
 			parts = append(parts, NthParts{nth: nth})
 		}
@@ -899,7 +950,7 @@ func nthTransformer(str string) (func(Delimiter) func([]Token, int32) string, er
 		parts = append(parts, NthParts{str: str[idx:]})
 	}
 
-	return func(delimiter Delimiter) func([]Token, int32) string {
+	return func(delimiter Delimiter, sourceName func(int32) string) func([]Token, int32) string {
 		return func(tokens []Token, index int32) string {
 			str := ""
 			for _, holder := range parts {
@@ -909,6 +960,10 @@ func nthTransformer(str string) (func(Delimiter) func([]Token, int32) string, er
 					if index >= 0 {
 						str += strconv.Itoa(int(index))
 					}
+				} else if holder.source {
+					if sourceName != nil {
+						str += sourceName(index)
+					}
 				} else {
 					str += holder.str
 				}
@@ -1081,6 +1136,10 @@ func parseKeyChords(str string, message string) (map[tui.Event]string, []tui.Eve
 			add(tui.ClickFooter)
 		case "multi":
 			add(tui.Multi)
+		case "normal-mode":
+			add(tui.NormalMode)
+		case "insert-mode":
+			add(tui.InsertMode)
 		case "alt-enter", "alt-return":
 			evt := tui.CtrlAltKey('m')
 			chords[evt] = key
@@ -1269,8 +1328,8 @@ func parseKeyChords(str string, message string) (map[tui.Event]string, []tui.Eve
 			add(tui.F12)
 		default:
 			runes := []rune(key)
-			if strings.HasPrefix(lkey, "every(") && strings.HasSuffix(lkey, ")") {
-				evt, err := parseEveryEvent(key[6 : len(key)-1])
+			if timerType, name, ok := parseTimerEventName(lkey); ok {
+				evt, err := parseTimerEvent(timerType, name, key[len(name)+1:len(key)-1])
 				if err != nil {
 					return nil, list, err
 				}
@@ -1318,19 +1377,37 @@ func parseKeyChords(str string, message string) (map[tui.Event]string, []tui.Eve
 	return chords, list, nil
 }
 
-func parseEveryEvent(arg string) (tui.Event, error) {
+// parseTimerEventName checks if the key is one of the events that take a
+// duration as the argument, i.e. every(SECS), idle(SECS), and change(SECS)
+func parseTimerEventName(lkey string) (tui.EventType, string, bool) {
+	name, _, found := strings.Cut(lkey, "(")
+	if !found || !strings.HasSuffix(lkey, ")") {
+		return 0, "", false
+	}
+	switch name {
+	case "every":
+		return tui.Every, name, true
+	case "idle":
+		return tui.Idle, name, true
+	case "change":
+		return tui.DebouncedChange, name, true
+	}
+	return 0, "", false
+}
+
+func parseTimerEvent(eventType tui.EventType, name string, arg string) (tui.Event, error) {
 	secs, err := strconv.ParseFloat(strings.TrimSpace(arg), 64)
 	if err != nil || math.IsNaN(secs) || math.IsInf(secs, 0) || secs <= 0 {
-		return tui.Event{}, errors.New("every() requires a positive number of seconds")
+		return tui.Event{}, errors.New(name + "() requires a positive number of seconds")
 	}
 	if secs < 0.01 {
 		secs = 0.01
 	}
 	ms := math.Round(secs * 1000)
 	if ms > math.MaxInt32 {
-		return tui.Event{}, errors.New("every() interval is too large")
+		return tui.Event{}, errors.New(name + "() interval is too large")
 	}
-	return tui.Event{Type: tui.Every, Char: rune(int32(ms))}, nil
+	return tui.Event{Type: eventType, Char: rune(int32(ms))}, nil
 }
 
 func parseScheme(str string) (string, []criterion, error) {
@@ -1354,6 +1431,7 @@ func parseTiebreak(str string) ([]criterion, error) {
 	hasBegin := false
 	hasEnd := false
 	hasPathname := false
+	hasSource := false
 	check := func(notExpected *bool, name string) error {
 		if *notExpected {
 			return errors.New("duplicate sort criteria: " + name)
@@ -1395,6 +1473,11 @@ func parseTiebreak(str string) ([]criterion, error) {
 				return nil, err
 			}
 			criteria = append(criteria, byEnd)
+		case "source":
+			if err := check(&hasSource, "source"); err != nil {
+				return nil, err
+			}
+			criteria = append(criteria, bySource)
 		default:
 			return nil, errors.New("invalid sort criterion: " + str)
 		}
@@ -1672,7 +1755,7 @@ const (
 
 func init() {
 	argActionRegexp = regexp.MustCompile(
-		`(?si)[:+](become|execute(?:-multi|-silent)?|reload(?:-sync)?|preview|(?:change|bg-transform|transform)-(?:query|prompt|(?:border|list|preview|input|header|footer)-label|header-lines|header|footer|search|with-nth|nth|pointer|ghost)|bg-transform|transform|change-(?:preview-window|preview|multi)|(?:re|un|toggle-)bind|pos|put|print|search|trigger)`)
+		`(?si)[:+](become|execute(?:-multi|-silent)?|reload(?:-sync|-source)?|preview|(?:change|bg-transform|transform)-(?:query|prompt|(?:border|list|preview|input|header|footer)-label|header-lines|header|footer|search|with-nth|nth|pointer|ghost)|bg-transform|transform|change-(?:preview-window|preview|multi)|(?:re|un|toggle-)bind|(?:start|play)-macro|pos|put|print|search|trigger)`)
 	splitRegexp = regexp.MustCompile("[,:]+")
 	actionNameRegexp = regexp.MustCompile("(?i)^[a-z-]+")
 }
@@ -1910,6 +1993,18 @@ func parseActionList(masked string, original string, prevActions []*action, putA
 			appendAction(actPrevHistory)
 		case "next-history":
 			appendAction(actNextHistory)
+		case "search-history":
+			appendAction(actSearchHistory)
+		case "undo":
+			appendAction(actUndo)
+		case "redo":
+			appendAction(actRedo)
+		case "start-macro":
+			appendAction(actStartMacro)
+		case "stop-macro":
+			appendAction(actStopMacro)
+		case "play-macro":
+			appendAction(actPlayMacro)
 		case "up-selected", "prev-selected":
 			appendAction(actPrevSelected)
 		case "down-selected", "next-selected":
@@ -1993,7 +2088,11 @@ func parseActionList(masked string, original string, prevActions []*action, putA
 				}
 				switch t {
 				case actUnbind, actRebind, actToggleBind:
-					if _, _, err := parseKeyChords(actionArg, spec[0:offset]+" target required"); err != nil {
+					if _, _, err := parseBindTargets(actionArg, spec[0:offset]+" target required"); err != nil {
+						return nil, err
+					}
+				case actStartMacro, actPlayMacro:
+					if err := validateMacroName(actionArg); err != nil {
 						return nil, err
 					}
 				case actChangePreviewWindow:
@@ -2012,7 +2111,7 @@ func parseActionList(masked string, original string, prevActions []*action, putA
 	return actions, nil
 }
 
-func parseKeymap(keymap map[tui.Event][]*action, str string) error {
+func parseKeymap(keymap map[tui.Event][]*action, sequences map[string][]*action, str string) error {
 	var err error
 	masked := maskActionContents(str)
 	idx := 0
@@ -2037,6 +2136,19 @@ func parseKeymap(keymap map[tui.Event][]*action, str string) error {
 				key = tui.Key(',')
 			} else if len(keyName) == 1 && keyName[0] == escapedPlus {
 				key = tui.Key('+')
+			} else if isKeySequence(keyName) {
+				name, err := parseKeySequence(keyName)
+				if err != nil {
+					return err
+				}
+				if sequences == nil {
+					return errors.New("key sequence not allowed: " + keyName)
+				}
+				sequences[name], err = parseActionList(pair[1], origPairStr[len(pair[0])+1:], sequences[name], false)
+				if err != nil {
+					return err
+				}
+				continue
 			} else {
 				keys, _, err := parseKeyChords(keyName, "key name required")
 				if err != nil {
@@ -2072,6 +2184,8 @@ func isExecuteAction(str string) actionType {
 		return actReload
 	case "reload-sync":
 		return actReloadSync
+	case "reload-source":
+		return actReloadSource
 	case "unbind":
 		return actUnbind
 	case "rebind":
@@ -2200,6 +2314,10 @@ func isExecuteAction(str string) actionType {
 		return actTrigger
 	case "search":
 		return actSearch
+	case "start-macro":
+		return actStartMacro
+	case "play-macro":
+		return actPlayMacro
 	}
 	return actIgnore
 }
@@ -2527,19 +2645,31 @@ func optString(arg string, prefix string) (bool, string) {
 func parseOptions(index *int, opts *Options, allArgs []string) error {
 	var err error
 	var historyMax int
+	var historyContext string
 	if opts.History == nil {
 		historyMax = defaultHistoryMax
 	} else {
 		historyMax = opts.History.maxSize
+		historyContext = opts.History.context
 	}
 	setHistory := func(path string) error {
 		h, e := NewHistory(path, historyMax)
 		if e != nil {
 			return e
 		}
+		if e := h.setContext(historyContext); e != nil {
+			return e
+		}
 		opts.History = h
 		return nil
 	}
+	setHistoryContext := func(context string) error {
+		historyContext = context
+		if opts.History != nil {
+			return opts.History.setContext(historyContext)
+		}
+		return nil
+	}
 	setHistoryMax := func(max int) error {
 		historyMax = max
 		if historyMax < 1 {
@@ -2560,6 +2690,7 @@ func parseOptions(index *int, opts *Options, allArgs []string) error {
 		opts.Help = false
 		opts.Version = false
 		opts.Man = false
+		opts.Remote = nil
 	}
 
 	startIndex := *index
@@ -2780,7 +2911,7 @@ func parseOptions(index *int, opts *Options, allArgs []string) error {
 			if err != nil {
 				return err
 			}
-			if err := parseKeymap(opts.Keymap, str); err != nil {
+			if err := parseKeymap(opts.Keymap, opts.Sequences, str); err != nil {
 				return err
 			}
 		case "--color":
@@ -3029,6 +3160,29 @@ func parseOptions(index *int, opts *Options, allArgs []string) error {
 		case "--no-scrollbar":
 			noBar := ""
 			opts.Scrollbar = &noBar
+		case "--vi-mode":
+			opts.ViMode = true
+		case "--no-vi-mode":
+			opts.ViMode = false
+		case "--sequence-timeout":
+			n, err := nextInt("timeout in milliseconds required")
+			if err != nil {
+				return err
+			}
+			if n <= 0 {
+				return errors.New("sequence timeout must be a positive integer")
+			}
+			opts.SequenceTimeout = time.Duration(n) * time.Millisecond
+		case "--macro-file":
+			str, err := nextString("macro file path required")
+			if err != nil {
+				return err
+			}
+			if opts.Macros, err = NewMacros(str); err != nil {
+				return err
+			}
+		case "--no-macro-file":
+			opts.Macros = nil
 		case "--jump-labels":
 			if opts.JumpLabels, err = nextString("label characters required"); err != nil {
 				return err
@@ -3046,6 +3200,65 @@ func parseOptions(index *int, opts *Options, allArgs []string) error {
 			opts.ReadZero = true
 		case "--no-read0":
 			opts.ReadZero = false
+		case "--csv":
+			opts.Delimiter = csvDelimiter(",")
+		case "--tsv":
+			opts.Delimiter = csvDelimiter("\t")
+		case "--input-file":
+			str, err := nextString("file path required")
+			if err != nil {
+				return err
+			}
+			opts.InputFile = str
+		case "--no-input-file":
+			opts.InputFile = ""
+		case "--input-decompress":
+			opts.InputDecompress = true
+		case "--no-input-decompress":
+			opts.InputDecompress = false
+		case "--dedup":
+			given, str := optionalNextString()
+			opts.Dedup = true
+			opts.DedupNth = nil
+			if given {
+				nth, err := splitNth(str)
+				if err != nil {
+					return err
+				}
+				opts.DedupNth = nth
+			}
+		case "--no-dedup":
+			opts.Dedup = false
+			opts.DedupNth = nil
+		case "--dedup-keep":
+			str, err := nextString("dedup-keep required [first|last]")
+			if err != nil {
+				return err
+			}
+			switch strings.ToLower(str) {
+			case "first":
+				opts.DedupLast = false
+			case "last":
+				opts.DedupLast = true
+			default:
+				return errors.New("invalid dedup-keep option: " + str + " (expected: first|last)")
+			}
+		case "--source":
+			str, err := nextString("source definition required")
+			if err != nil {
+				return err
+			}
+			source, err := parseSource(str, opts.Sources)
+			if err != nil {
+				return err
+			}
+			opts.Sources = append(opts.Sources, source)
+		case "--no-source":
+			opts.Sources = nil
+		case "--no-csv", "--no-tsv":
+			if opts.Delimiter.csv {
+				opts.Delimiter = Delimiter{}
+			}
 		case "--print0":
 			opts.Printer = func(str string) { fmt.Print(str, "\x00") }
 			opts.PrintSep = "\x00"
@@ -3119,6 +3332,21 @@ func parseOptions(index *int, opts *Options, allArgs []string) error {
 			if err := setHistoryMax(n); err != nil {
 				return err
 			}
+		case "--history-context":
+			str, err := nextString("history context required")
+			if err != nil {
+				return err
+			}
+			if len(str) == 0 {
+				return errors.New("history context must not be empty")
+			}
+			if err := setHistoryContext(str); err != nil {
+				return err
+			}
+		case "--no-history-context":
+			if err := setHistoryContext(""); err != nil {
+				return err
+			}
 		case "--no-header":
 			opts.Header = []string{}
 		case "--no-header-lines":
@@ -3418,6 +3646,42 @@ func parseOptions(index *int, opts *Options, allArgs []string) error {
 		case "--no-listen", "--no-listen-unsafe":
 			opts.ListenAddr = nil
 			opts.Unsafe = false
+		case "--listen-tls":
+			_, str := optionalNextString()
+			listenTLS, err := parseTLSOpts(str)
+			if err != nil {
+				return err
+			}
+			opts.ListenTLS = &listenTLS
+		case "--no-listen-tls":
+			opts.ListenTLS = nil
+		case "--record":
+			if opts.Record, err = nextString("file path required"); err != nil {
+				return err
+			}
+		case "--no-record":
+			opts.Record = ""
+		case "--replay":
+			if opts.Replay, err = nextString("file path required"); err != nil {
+				return err
+			}
+		case "--no-replay":
+			opts.Replay = ""
+		case "--remote":
+			// Address is taken from the environment unless given with '='
+			addr := listenAddress{}
+			if val != nil {
+				var err error
+				if addr, err = parseListenAddress(*val); err != nil {
+					return err
+				}
+				val = nil
+			}
+			clearExitingOpts()
+			opts.Remote = &addr
+			// The rest of the arguments are the actions to send
+			opts.RemoteActions = allArgs[i+1:]
+			i = len(allArgs)
 		case "--clear":
 			opts.ClearOnExit = true
 		case "--no-clear":
@@ -3669,6 +3933,10 @@ func validateOptions(opts *Options) error {
 		return errors.New("--header-border=inline requires --header-lines-border to be inline or unset")
 	}
 
+	if len(opts.Record) > 0 && len(opts.Replay) > 0 {
+		return errors.New("--record and --replay are mutually exclusive")
+	}
+
 	return nil
 }
 
@@ -3826,6 +4094,13 @@ func postProcessOptions(opts *Options) error {
 		keymap[key] = actions
 	}
 	opts.Keymap = keymap
+	for _, actions := range opts.Sequences {
+		for _, act := range actions {
+			if act.t == actToggleSort {
+				opts.ToggleSort = true
+			}
+		}
+	}
 
 	// If 'double-click' is left unbound, bind it to the action bound to 'enter'
 	if _, prs := opts.Keymap[tui.DoubleClick.AsEvent()]; !prs {
@@ -267,7 +267,7 @@ func TestBind(t *testing.T) {
 		}
 	}
 	check(tui.CtrlA.AsEvent(), "", actBeginningOfLine)
-	parseKeymap(keymap,
+	parseKeymap(keymap, nil,
 		"ctrl-a:kill-line,ctrl-b:toggle-sort+up+down,c:page-up,alt-z:page-down,"+
 			"f1:execute(ls {+})+abort+execute(echo \n{+})+select-all,f2:execute/echo {}, {}, {}/,f3:execute[echo '({})'],f4:execute;less {};,"+
 			"alt-a:execute-Multi@echo (,),[,],/,:,;,%,{}@,alt-b:execute;echo (,),[,],/,:,@,%,{};,"+
@@ -291,11 +291,11 @@ func TestBind(t *testing.T) {
 	check(tui.Key('+'), "++\nfoobar,Y:execute(baz)+up", actExecute)
 
 	for idx, char := range []rune{'~', '!', '@', '#', '$', '%', '^', '&', '*', '|', ';', '/'} {
-		parseKeymap(keymap, fmt.Sprintf("%d:execute%cfoobar%c", idx%10, char, char))
+		parseKeymap(keymap, nil, fmt.Sprintf("%d:execute%cfoobar%c", idx%10, char, char))
 		check(tui.Key([]rune(fmt.Sprintf("%d", idx%10))[0]), "foobar", actExecute)
 	}
 
-	parseKeymap(keymap, "f1:abort")
+	parseKeymap(keymap, nil, "f1:abort")
 	check(tui.F1.AsEvent(), "", actAbort)
 }
 
@@ -332,6 +332,27 @@ func TestParseEveryEvent(t *testing.T) {
 
 }
 
+func TestParseTimerEvents(t *testing.T) {
+	pairs, _, err := parseKeyChords("idle(1.5),change(0.3),change", "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if pairs[(tui.Event{Type: tui.Idle, Char: 1500})] != "idle(1.5)" {
+		t.Errorf("idle(1.5) not registered")
+	}
+	if pairs[(tui.Event{Type: tui.DebouncedChange, Char: 300})] != "change(0.3)" {
+		t.Errorf("change(0.3) not registered")
+	}
+	if pairs[tui.Change.AsEvent()] != "change" {
+		t.Errorf("change not registered")
+	}
+	for _, bad := range []string{"idle(0)", "idle()", "change(-1)", "change(x)", "foo(1)"} {
+		if _, _, err := parseKeyChords(bad, ""); err == nil {
+			t.Errorf("%s should be rejected", bad)
+		}
+	}
+}
+
 func TestColorSpec(t *testing.T) {
 	var base *tui.ColorTheme
 	theme := tui.Dark256
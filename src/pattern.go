@@ -65,7 +65,9 @@ type Pattern struct {
 	procFun       [7]algo.Algo
 	cache         *ChunkCache
 	denylist      map[int32]struct{}
+	duplicates    map[int32]struct{}
 	startIndex    int32
+	sources       *sourceTable
 	directAlgo    algo.Algo
 	directTerm    *term
 }
@@ -78,7 +80,7 @@ func init() {
 
 // BuildPattern builds Pattern object from the given arguments
 func BuildPattern(cache *ChunkCache, patternCache map[string]*Pattern, fuzzy bool, fuzzyAlgo algo.Algo, extended bool, caseMode Case, normalize bool, forward bool,
-	withPos bool, cacheable bool, nth []Range, delimiter Delimiter, revision revision, runes []rune, denylist map[int32]struct{}, startIndex int32) *Pattern {
+	withPos bool, cacheable bool, nth []Range, delimiter Delimiter, revision revision, runes []rune, denylist map[int32]struct{}, duplicates map[int32]struct{}, startIndex int32, sources *sourceTable) *Pattern {
 
 	var asString string
 	if extended {
@@ -150,7 +152,9 @@ func BuildPattern(cache *ChunkCache, patternCache map[string]*Pattern, fuzzy boo
 		delimiter:     delimiter,
 		cache:         cache,
 		denylist:      denylist,
+		duplicates:    duplicates,
 		startIndex:    startIndex,
+		sources:       sources,
 	}
 
 	ptr.cacheKey = ptr.buildCacheKey()
@@ -257,7 +261,7 @@ func parseTerms(fuzzy bool, caseMode Case, normalize bool, str string) []termSet
 
 // IsEmpty returns true if the pattern is effectively empty
 func (p *Pattern) IsEmpty() bool {
-	if len(p.denylist) > 0 {
+	if p.hasDenylist() {
 		return false
 	}
 	if !p.extended {
@@ -266,6 +270,11 @@ func (p *Pattern) IsEmpty() bool {
 	return len(p.termSets) == 0
 }
 
+// hasDenylist returns true if any item is to be excluded from the result
+func (p *Pattern) hasDenylist() bool {
+	return len(p.denylist) > 0 || len(p.duplicates) > 0
+}
+
 // AsString returns the search query in string type
 func (p *Pattern) AsString() string {
 	return string(p.text)
@@ -344,7 +353,7 @@ func (p *Pattern) matchChunk(chunk *Chunk, cachedBitmap *ChunkBitmap, slab *util
 	// Fast path: single fuzzy term, no nth, no denylist.
 	// Calls the algo function directly, bypassing MatchItem/extendedMatch/iter
 	// and avoiding per-match []Offset heap allocation.
-	if p.directAlgo != nil && len(p.denylist) == 0 {
+	if p.directAlgo != nil && !p.hasDenylist() {
 		t := p.directTerm
 		for idx := startIdx; idx < chunk.count; idx++ {
 			if hasCachedBitmap && cachedBitmap[idx/64]&(uint64(1)<<(idx%64)) == 0 {
@@ -356,13 +365,13 @@ func (p *Pattern) matchChunk(chunk *Chunk, cachedBitmap *ChunkBitmap, slab *util
 				bitmap[idx/64] |= uint64(1) << (idx % 64)
 				matches = append(matches, buildResultFromBounds(
 					&chunk.items[idx], res.Score,
-					int(res.Start), int(res.End), int(res.End), true))
+					int(res.Start), int(res.End), int(res.End), true, p.sources))
 			}
 		}
 		return matches, bitmap
 	}
 
-	if len(p.denylist) == 0 {
+	if !p.hasDenylist() {
 		for idx := startIdx; idx < chunk.count; idx++ {
 			if hasCachedBitmap && cachedBitmap[idx/64]&(uint64(1)<<(idx%64)) == 0 {
 				continue
@@ -379,7 +388,11 @@ func (p *Pattern) matchChunk(chunk *Chunk, cachedBitmap *ChunkBitmap, slab *util
 		if hasCachedBitmap && cachedBitmap[idx/64]&(uint64(1)<<(idx%64)) == 0 {
 			continue
 		}
-		if _, prs := p.denylist[chunk.items[idx].Index()]; prs {
+		index := chunk.items[idx].Index()
+		if _, prs := p.denylist[index]; prs {
+			continue
+		}
+		if _, prs := p.duplicates[index]; prs {
 			continue
 		}
 		if match, _, _ := p.MatchItem(&chunk.items[idx], p.withPos, slab); match.item != nil {
@@ -393,18 +406,24 @@ func (p *Pattern) matchChunk(chunk *Chunk, cachedBitmap *ChunkBitmap, slab *util
 // MatchItem returns the match result if the Item is a match.
 // A zero-value Result (with item == nil) indicates no match.
 func (p *Pattern) MatchItem(item *Item, withPos bool, slab *util.Slab) (Result, []Offset, *[]int) {
+	result, offsets, pos, _ := p.matchItem(item, withPos, slab)
+	return result, offsets, pos
+}
+
+// matchItem is MatchItem that also returns the score of the match
+func (p *Pattern) matchItem(item *Item, withPos bool, slab *util.Slab) (Result, []Offset, *[]int, int) {
 	if p.extended {
 		if offsets, bonus, pos := p.extendedMatch(item, withPos, slab); len(offsets) == len(p.termSets) {
-			return buildResult(item, offsets, bonus), offsets, pos
+			return buildResult(item, offsets, bonus, p.sources), offsets, pos, bonus
 		}
-		return Result{}, nil, nil
+		return Result{}, nil, nil, 0
 	}
 	offset, bonus, pos := p.basicMatch(item, withPos, slab)
 	if sidx := offset[0]; sidx >= 0 {
 		offsets := []Offset{offset}
-		return buildResult(item, offsets, bonus), offsets, pos
+		return buildResult(item, offsets, bonus, p.sources), offsets, pos, bonus
 	}
-	return Result{}, nil, nil
+	return Result{}, nil, nil, 0
 }
 
 func (p *Pattern) basicMatch(item *Item, withPos bool, slab *util.Slab) (Offset, int, *[]int) {
@@ -0,0 +1,158 @@
+package fzf
+
+import (
+	"bytes"
+	"errors"
+	"maps"
+	"slices"
+	"sync"
+)
+
+type pushOp int
+
+const (
+	pushAppend pushOp = iota
+	pushReplace
+	pushRemove
+)
+
+// pushRequest is a request to change the items of the list via the server
+type pushRequest struct {
+	op    pushOp
+	lines [][]byte
+}
+
+// requestQueue holds the requests from the server until the main event loop
+// processes them
+type requestQueue[T any] struct {
+	mutex    sync.Mutex
+	requests []T
+}
+
+func (q *requestQueue[T]) add(request T) {
+	q.mutex.Lock()
+	q.requests = append(q.requests, request)
+	q.mutex.Unlock()
+}
+
+func (q *requestQueue[T]) drain() []T {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	requests := q.requests
+	q.requests = nil
+	return requests
+}
+
+func parsePushOp(str string) (pushOp, error) {
+	switch str {
+	case "append":
+		return pushAppend, nil
+	case "replace":
+		return pushReplace, nil
+	case "remove":
+		return pushRemove, nil
+	}
+	return pushAppend, errors.New("invalid operation: " + str)
+}
+
+// splitPushedLines splits the body of the request into lines
+func splitPushedLines(body []byte, delim byte) [][]byte {
+	lines := [][]byte{}
+	for _, line := range bytes.Split(body, []byte{delim}) {
+		if delim == '\n' {
+			line = bytes.TrimSuffix(line, []byte("\r"))
+		}
+		if len(line) > 0 {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// itemKey returns the key of the item for the given --id-nth expression
+func itemKey(text string, nth []Range, delimiter Delimiter) string {
+	tokens := Tokenize(text, delimiter)
+	return StripLastDelimiter(JoinTokens(Transform(tokens, nth)), delimiter)
+}
+
+// pushIndex maps the --id-nth keys of the items to their indexes, so that the
+// items to be removed or replaced are found without scanning the whole list.
+// The items added after the last request are indexed on the next one.
+type pushIndex struct {
+	nth       []Range
+	delimiter Delimiter
+	ansi      bool
+	keys      map[string][]int32
+	size      int   // Number of the indexes in keys
+	next      int32 // Index of the first item not yet indexed
+	removed   map[int32]struct{}
+}
+
+func newPushIndex(opts *Options) *pushIndex {
+	index := &pushIndex{nth: opts.IdNth, delimiter: opts.Delimiter, ansi: opts.Ansi}
+	index.clear()
+	return index
+}
+
+func (p *pushIndex) clear() {
+	p.keys = make(map[string][]int32)
+	p.size = 0
+	p.next = 0
+	p.removed = make(map[int32]struct{})
+}
+
+func (p *pushIndex) update(cl *ChunkList) {
+	cl.ForEachItemFrom(p.next, func(item *Item) {
+		key := itemKey(item.AsString(p.ansi), p.nth, p.delimiter)
+		p.keys[key] = append(p.keys[key], item.Index())
+		p.size++
+		p.next = item.Index() + 1
+	})
+}
+
+// remove returns the indexes of the items that have the same keys as the
+// given lines. The items before minIndex (header lines) are not removed.
+func (p *pushIndex) remove(cl *ChunkList, lines [][]byte, minIndex int32) []int32 {
+	p.update(cl)
+	removed := []int32{}
+	for _, line := range lines {
+		text := byteString(line)
+		if p.ansi {
+			text, _, _ = extractColor(text, nil, nil)
+		}
+		key := itemKey(text, p.nth, p.delimiter)
+		kept := []int32{}
+		for _, index := range p.keys[key] {
+			if index < minIndex {
+				kept = append(kept, index)
+				continue
+			}
+			removed = append(removed, index)
+			p.removed[index] = struct{}{}
+		}
+		p.size -= len(p.keys[key]) - len(kept)
+		if len(kept) > 0 {
+			p.keys[key] = kept
+		} else {
+			delete(p.keys, key)
+		}
+	}
+	return removed
+}
+
+// compact drops the removed items from the list when they take up a large
+// part of it, and returns their indexes to be cleared from the denylist. The index is also rebuilt when it
+// holds many items that are no longer in the list (e.g. trimmed by --tail).
+func (p *pushIndex) compact(cl *ChunkList, count int) []int32 {
+	live := count - len(p.removed)
+	if len(p.removed) <= max(chunkSize, live) && p.size <= 2*max(chunkSize, live) {
+		return nil
+	}
+	cl.Filter(func(item *Item) bool {
+		_, prs := p.removed[item.Index()]
+		return !prs
+	}, nil)
+	removed := slices.Collect(maps.Keys(p.removed))
+	p.clear()
+	return removed
+}
@@ -0,0 +1,135 @@
+package fzf
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/junegunn/fzf/src/util"
+)
+
+func TestSplitPushedLines(t *testing.T) {
+	lines := splitPushedLines([]byte("foo\r\nbar\n\nbaz"), '\n')
+	if len(lines) != 3 || string(lines[0]) != "foo" || string(lines[1]) != "bar" || string(lines[2]) != "baz" {
+		t.Errorf("invalid lines: %q", lines)
+	}
+	lines = splitPushedLines([]byte("foo\nbar\x00baz\x00"), 0)
+	if len(lines) != 2 || string(lines[0]) != "foo\nbar" || string(lines[1]) != "baz" {
+		t.Errorf("invalid lines: %q", lines)
+	}
+}
+
+func TestParsePushOp(t *testing.T) {
+	for str, expected := range map[string]pushOp{"append": pushAppend, "replace": pushReplace, "remove": pushRemove} {
+		if op, err := parsePushOp(str); err != nil || op != expected {
+			t.Errorf("%s: %v, %v", str, op, err)
+		}
+	}
+	if _, err := parsePushOp("foo"); err == nil {
+		t.Error("should not accept unknown operation")
+	}
+}
+
+func TestItemKey(t *testing.T) {
+	nth, _ := splitNth("1")
+	delimiter := delimiterRegexp(":")
+	if key := itemKey("foo.go:12:bar", nth, delimiter); key != "foo.go" {
+		t.Errorf("invalid key: %s", key)
+	}
+	if key := itemKey("foo.go", nth, delimiter); key != "foo.go" {
+		t.Errorf("invalid key: %s", key)
+	}
+}
+
+func TestPushQueue(t *testing.T) {
+	queue := requestQueue[pushRequest]{}
+	queue.add(pushRequest{pushAppend, [][]byte{[]byte("foo")}})
+	queue.add(pushRequest{pushRemove, [][]byte{[]byte("bar")}})
+	requests := queue.drain()
+	if len(requests) != 2 || requests[0].op != pushAppend || requests[1].op != pushRemove {
+		t.Errorf("invalid requests: %v", requests)
+	}
+	if len(queue.drain()) != 0 {
+		t.Error("queue should be empty")
+	}
+}
+
+func TestPushIndex(t *testing.T) {
+	var index int32
+	cl := NewChunkList(NewChunkCache(), func(item *Item, s []byte, source int) bool {
+		item.text = util.ToChars(s)
+		item.text.Index = index
+		index++
+		return true
+	})
+	nth, _ := splitNth("1")
+	pushed := newPushIndex(&Options{IdNth: nth, Delimiter: delimiterRegexp(":")})
+
+	// Header line is not removed
+	for _, line := range []string{"foo:header", "foo:1", "bar:2", "foo:3"} {
+		cl.Push([]byte(line), noSource)
+	}
+	if removed := pushed.remove(cl, [][]byte{[]byte("foo:x")}, 1); len(removed) != 2 || removed[0] != 1 || removed[1] != 3 {
+		t.Errorf("unexpected indexes: %v", removed)
+	}
+	if removed := pushed.remove(cl, [][]byte{[]byte("foo:x")}, 1); len(removed) != 0 {
+		t.Errorf("should not be removed again: %v", removed)
+	}
+
+	// Repeated replaces should not grow the list without limit
+	denylist := make(map[int32]struct{})
+	for i := range chunkSize * 4 {
+		line := fmt.Appendf(nil, "bar:%d", i)
+		for _, index := range pushed.remove(cl, [][]byte{line}, 1) {
+			denylist[index] = struct{}{}
+		}
+		cl.Push(line, noSource)
+		_, count, _ := cl.Snapshot(0)
+		for _, index := range pushed.compact(cl, count) {
+			delete(denylist, index)
+		}
+	}
+	snapshot, count, _ := cl.Snapshot(0)
+	if count > 3+chunkSize*2 || len(denylist) > chunkSize*2 {
+		t.Errorf("too many items: %d, %d", count, len(denylist))
+	}
+	live := []string{}
+	for _, chunk := range snapshot {
+		for i := 0; i < chunk.count; i++ {
+			if _, prs := denylist[chunk.items[i].Index()]; !prs {
+				live = append(live, chunk.items[i].text.ToString())
+			}
+		}
+	}
+	if len(live) != 2 || live[0] != "foo:header" || live[1] != fmt.Sprintf("bar:%d", chunkSize*4-1) {
+		t.Errorf("unexpected items: %v", live)
+	}
+}
+
+func TestPushIndexDedup(t *testing.T) {
+	var index int32
+	dedup := newDeduplicator(&Options{Dedup: true})
+	cl := NewChunkList(NewChunkCache(), func(item *Item, s []byte, source int) bool {
+		if keep, _ := dedup.check(s, index); !keep {
+			return false
+		}
+		item.text = util.ToChars(s)
+		item.text.Index = index
+		index++
+		return true
+	})
+	pushed := newPushIndex(&Options{})
+
+	// An equal replacement line is not dropped as a duplicate
+	cl.Push([]byte("foo"), noSource)
+	removed := pushed.remove(cl, [][]byte{[]byte("foo")}, 0)
+	if len(removed) != 1 || removed[0] != 0 {
+		t.Errorf("unexpected indexes: %v", removed)
+	}
+	dedup.forget(removed)
+	if !cl.Push([]byte("foo"), noSource) {
+		t.Error("replacement line should be added")
+	}
+	if cl.Push([]byte("foo"), noSource) {
+		t.Error("duplicate line should be dropped")
+	}
+}
@@ -3,9 +3,11 @@ package fzf
 import (
 	"bytes"
 	"context"
+	"fmt"
 	"io"
 	"io/fs"
 	"os"
+	"os/exec"
 	"path/filepath"
 	"slices"
 	"strings"
@@ -19,33 +21,39 @@ import (
 
 // Reader reads from command or standard input
 type Reader struct {
-	pusher   func([]byte) bool
-	executor *util.Executor
-	eventBox *util.EventBox
-	delimNil bool
-	event    int32
-	finChan  chan bool
-	mutex    sync.Mutex
-	killed   bool
-	termFunc func()
-	command  *string
-	wait     bool
+	pusher     func([]byte, int) bool
+	executor   *util.Executor
+	eventBox   *util.EventBox
+	delimNil   bool
+	csvSep     byte // Separator of CSV input, or 0 if the input is not CSV
+	decompress bool
+	event      int32
+	finChan    chan bool
+	mutex      sync.Mutex
+	killed     bool
+	termFunc   func()
+	command    *string
+	wait       bool
+	err        error // Error from the input, e.g. corrupt compressed data
 }
 
 // NewReader returns new Reader object
-func NewReader(pusher func([]byte) bool, eventBox *util.EventBox, executor *util.Executor, delimNil bool, wait bool) *Reader {
+func NewReader(pusher func([]byte, int) bool, eventBox *util.EventBox, executor *util.Executor, delimNil bool, csvSep byte, decompress bool, wait bool) *Reader {
 	return &Reader{
 		pusher,
 		executor,
 		eventBox,
 		delimNil,
+		csvSep,
+		decompress,
 		int32(EvtReady),
 		make(chan bool, 1),
 		sync.Mutex{},
 		false,
 		func() { os.Stdin.Close() },
 		nil,
-		wait}
+		wait,
+		nil}
 }
 
 func (r *Reader) startEventPoller() {
@@ -88,6 +96,13 @@ func (r *Reader) fin(success bool) {
 	r.eventBox.Set(EvtReadFin, ret)
 }
 
+// readError returns the error from the input that stopped reading
+func (r *Reader) readError() error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return r.err
+}
+
 func (r *Reader) terminate() {
 	r.mutex.Lock()
 	r.killed = true
@@ -98,12 +113,21 @@ func (r *Reader) terminate() {
 	r.mutex.Unlock()
 }
 
-func (r *Reader) restart(command commandSpec, environ []string, readyChan chan bool) {
+func (r *Reader) restart(command commandSpec, source int, environ []string, readyChan chan bool) {
 	r.event = int32(EvtReady)
+	r.mutex.Lock()
+	r.err = nil
+	r.mutex.Unlock()
 	r.startEventPoller()
-	success := r.readFromCommand(command.command, environ, func() {
+	signalReady := func() {
 		readyChan <- true
-	})
+	}
+	var success bool
+	if source >= 0 {
+		success = r.readFromSources([]int{source}, []string{command.command}, environ, signalReady)
+	} else {
+		success = r.readFromCommand(command.command, environ, signalReady)
+	}
 	r.fin(success)
 	removeFiles(command.tempFiles)
 }
@@ -114,7 +138,7 @@ func (r *Reader) readChannel(inputChan chan string) bool {
 		if !more {
 			break
 		}
-		if r.pusher([]byte(item)) {
+		if r.pusher([]byte(item), noSource) {
 			atomic.StoreInt32(&r.event, int32(EvtReadNew))
 		}
 	}
@@ -122,7 +146,7 @@ func (r *Reader) readChannel(inputChan chan string) bool {
 }
 
 // ReadSource reads data from the default command or from standard input
-func (r *Reader) ReadSource(inputChan chan string, roots []string, opts walkerOpts, ignores []string, initCmd string, initEnv []string, readyChan chan bool) {
+func (r *Reader) ReadSource(inputChan chan string, sources []inputSource, inputFile string, roots []string, opts walkerOpts, ignores []string, initCmd string, initEnv []string, readyChan chan bool) {
 	r.startEventPoller()
 	var success bool
 	signalReady := func() {
@@ -133,8 +157,19 @@ func (r *Reader) ReadSource(inputChan chan string, roots []string, opts walkerOp
 	if inputChan != nil {
 		signalReady()
 		success = r.readChannel(inputChan)
+	} else if len(sources) > 0 {
+		ids := make([]int, len(sources))
+		commands := make([]string, len(sources))
+		for idx, source := range sources {
+			ids[idx] = idx
+			commands[idx] = source.command
+		}
+		success = r.readFromSources(ids, commands, initEnv, signalReady)
 	} else if len(initCmd) > 0 {
 		success = r.readFromCommand(initCmd, initEnv, signalReady)
+	} else if len(inputFile) > 0 {
+		signalReady()
+		success = r.readMapped(inputFile)
 	} else if util.IsTty(os.Stdin) {
 		cmd := os.Getenv("FZF_DEFAULT_COMMAND")
 		if len(cmd) == 0 {
@@ -150,7 +185,7 @@ func (r *Reader) ReadSource(inputChan chan string, roots []string, opts walkerOp
 	r.fin(success)
 }
 
-func (r *Reader) feed(src io.Reader) {
+func (r *Reader) feed(src io.Reader, source int) {
 	/*
 		readerSlabSize, ae := strconv.Atoi(os.Getenv("SLAB_KB"))
 		if ae != nil {
@@ -175,6 +210,8 @@ func (r *Reader) feed(src io.Reader) {
 
 	slab := make([]byte, readerSlabSize)
 	leftover := []byte{}
+	// A delimiter in a quoted CSV field is part of the record
+	scanner := csvScanner{sep: r.csvSep, fieldStart: true}
 	var err error
 	for {
 		n := 0
@@ -199,6 +236,10 @@ func (r *Reader) feed(src io.Reader) {
 				// Found the delimiter
 				slice := buf[:i+1]
 				buf = buf[i+1:]
+				if r.csvSep != 0 && !scanner.endOfRecord(slice) {
+					leftover = append(leftover, slice...)
+					continue
+				}
 				if trimCR && len(slice) >= 2 && slice[len(slice)-2] == byte('\r') {
 					slice = slice[:len(slice)-2]
 				} else {
@@ -208,7 +249,7 @@ func (r *Reader) feed(src io.Reader) {
 					slice = append(leftover, slice...)
 					leftover = []byte{}
 				}
-				if (err == nil || len(slice) > 0) && r.pusher(slice) {
+				if (err == nil || len(slice) > 0) && r.pusher(slice, source) {
 					atomic.StoreInt32(&r.event, int32(EvtReadNew))
 				}
 			} else {
@@ -219,6 +260,9 @@ func (r *Reader) feed(src io.Reader) {
 				//   another buffer. However, the performance gain is negligible in
 				//   practice (< 0.1%) and is not
 				//   worth the added complexity.
+				if r.csvSep != 0 {
+					scanner.scan(buf)
+				}
 				leftover = append(leftover, buf...)
 				break
 			}
@@ -233,16 +277,153 @@ func (r *Reader) feed(src io.Reader) {
 			slab = make([]byte, readerSlabSize)
 		}
 	}
-	if len(leftover) > 0 && r.pusher(leftover) {
+	if len(leftover) > 0 && r.pusher(leftover, source) {
 		atomic.StoreInt32(&r.event, int32(EvtReadNew))
 	}
 }
 
-func (r *Reader) readFromStdin() bool {
-	r.feed(os.Stdin)
+// csvScanner keeps track of the quoting of CSV input that is read in chunks.
+// As in csvTokenizer, a quote only starts a quoted field at the beginning of
+// a field, and it is a literal character in an unquoted field.
+type csvScanner struct {
+	sep        byte
+	quoted     bool
+	closed     bool // Just closed a quoted field; the next quote is escaped
+	fieldStart bool
+}
+
+func (s *csvScanner) scan(data []byte) {
+	for _, c := range data {
+		if s.quoted {
+			if c == '"' {
+				s.quoted = false
+				s.closed = true
+			}
+			continue
+		}
+		if s.closed && c == '"' {
+			s.quoted = true
+			s.closed = false
+			continue
+		}
+		s.closed = false
+		switch {
+		case c == s.sep:
+			s.fieldStart = true
+			continue
+		case c == '"' && s.fieldStart:
+			s.quoted = true
+		}
+		s.fieldStart = false
+	}
+}
+
+// endOfRecord scans the data that ends with the delimiter and returns true
+// if the delimiter is not in a quoted field
+func (s *csvScanner) endOfRecord(data []byte) bool {
+	s.scan(data)
+	if s.quoted {
+		return false
+	}
+	s.closed = false
+	s.fieldStart = true
+	return true
+}
+
+// readMapped memory-maps the file and pushes the slices of the mapping
+// without copying them. The mapping is kept for the lifetime of the process
+// as the items refer to it.
+func (r *Reader) readMapped(path string) bool {
+	if r.decompress {
+		return r.readFile(path)
+	}
+	data, err := mapFile(path)
+	if err != nil {
+		// Not a regular file (e.g. named pipe)
+		return r.readFile(path)
+	}
+
+	delim := byte('\n')
+	trimCR := util.IsWindows()
+	if r.delimNil {
+		delim = '\000'
+		trimCR = false
+	}
+
+	push := func(slice []byte) {
+		if trimCR && len(slice) > 0 && slice[len(slice)-1] == byte('\r') {
+			slice = slice[:len(slice)-1]
+		}
+		// Limit the capacity so that appending to the slice never writes to
+		// the mapping
+		if r.pusher(slice[:len(slice):len(slice)], noSource) {
+			atomic.StoreInt32(&r.event, int32(EvtReadNew))
+		}
+	}
+
+	begin := 0
+	offset := 0
+	nextCheck := readerSlabSize
+	scanner := csvScanner{sep: r.csvSep, fieldStart: true}
+	for offset < len(data) {
+		i := bytes.IndexByte(data[offset:], delim)
+		if i < 0 {
+			break
+		}
+		end := offset + i
+		slice := data[offset : end+1]
+		offset = end + 1
+		if r.csvSep != 0 && !scanner.endOfRecord(slice) {
+			continue
+		}
+		push(data[begin:end])
+		begin = offset
+
+		// Check if we should stop every once in a while
+		if offset >= nextCheck {
+			nextCheck = offset + readerSlabSize
+			r.mutex.Lock()
+			killed := r.killed
+			r.mutex.Unlock()
+			if killed {
+				return true
+			}
+		}
+	}
+	if begin < len(data) {
+		push(data[begin:])
+	}
+	return true
+}
+
+// feedInput feeds the data from the standard input or --input-file,
+// decompressing it if --input-decompress is set
+func (r *Reader) feedInput(src io.Reader) bool {
+	if !r.decompress {
+		r.feed(src, noSource)
+		return true
+	}
+	reader, err := decompressReader(src)
+	if err == nil {
+		defer reader.Close()
+		r.feed(reader, noSource)
+		err = reader.err
+	}
+	if err != nil {
+		r.mutex.Lock()
+		if !r.killed {
+			r.err = fmt.Errorf("decompression failed: %w", err)
+		}
+		r.mutex.Unlock()
+		return false
+	}
 	return true
 }
 
+func (r *Reader) readFromStdin() bool {
+	return r.feedInput(os.Stdin)
+}
+
 func isSymlinkToDir(path string, de os.DirEntry) bool {
 	if de.Type()&fs.ModeSymlink == 0 {
 		return false
@@ -364,7 +545,7 @@ func (r *Reader) readFiles(roots []string, opts walkerOpts, ignores []string) bo
 					path += sep
 				}
 			}
-			if ((opts.file && !isDir) || (opts.dir && isDir)) && r.pusher(stringBytes(path)) {
+			if ((opts.file && !isDir) || (opts.dir && isDir)) && r.pusher(stringBytes(path), noSource) {
 				atomic.StoreInt32(&r.event, int32(EvtReadNew))
 			}
 		}
@@ -382,6 +563,24 @@ func (r *Reader) readFiles(roots []string, opts walkerOpts, ignores []string) bo
 	return noerr
 }
 
+func (r *Reader) readFile(path string) bool {
+	file, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer file.Close()
+
+	r.mutex.Lock()
+	if r.killed {
+		r.mutex.Unlock()
+		return false
+	}
+	r.termFunc = func() { file.Close() }
+	r.mutex.Unlock()
+
+	return r.feedInput(file)
+}
+
 func (r *Reader) readFromCommand(command string, environ []string, signalReady func()) bool {
 	r.mutex.Lock()
 
@@ -408,6 +607,63 @@ func (r *Reader) readFromCommand(command string, environ []string, signalReady f
 	signalReady()
 	r.mutex.Unlock()
 
-	r.feed(execOut)
+	r.feed(execOut, noSource)
 	return exec.Wait() == nil
 }
+
+// readFromSources runs the commands of the given sources concurrently and
+// reads their output until all of them are complete
+func (r *Reader) readFromSources(ids []int, commands []string, environ []string, signalReady func()) bool {
+	r.mutex.Lock()
+
+	r.killed = false
+	r.termFunc = nil
+	r.command = nil
+	success := true
+	cmds := []*exec.Cmd{}
+	outputs := []io.ReadCloser{}
+	started := []int{}
+	for idx := range ids {
+		cmd := r.executor.ExecCommand(commands[idx], true)
+		if environ != nil {
+			cmd.Env = environ
+		}
+		cmdOut, err := cmd.StdoutPipe()
+		if err != nil || cmd.Start() != nil {
+			r.command = &commands[idx]
+			success = false
+			continue
+		}
+		cmds = append(cmds, cmd)
+		outputs = append(outputs, cmdOut)
+		started = append(started, idx)
+	}
+
+	// Function to call to terminate the running commands
+	r.termFunc = func() {
+		for idx, cmd := range cmds {
+			outputs[idx].Close()
+			util.KillCommand(cmd)
+		}
+	}
+
+	signalReady()
+	r.mutex.Unlock()
+
+	var waitGroup sync.WaitGroup
+	for idx, cmd := range cmds {
+		waitGroup.Add(1)
+		go func(id int) {
+			defer waitGroup.Done()
+			r.feed(outputs[idx], ids[id])
+			if cmd.Wait() != nil {
+				r.mutex.Lock()
+				success = false
+				r.command = &commands[id]
+				r.mutex.Unlock()
+			}
+		}(started[idx])
+	}
+	waitGroup.Wait()
+	return success
+}
@@ -1,7 +1,12 @@
 package fzf
 
 import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"testing/iotest"
 	"time"
 
 	"github.com/junegunn/fzf/src/util"
@@ -12,8 +17,8 @@ func TestReadFromCommand(t *testing.T) {
 	eb := util.NewEventBox()
 	exec := util.NewExecutor("")
 	reader := NewReader(
-		func(s []byte) bool { strs = append(strs, string(s)); return true },
-		eb, exec, false, true)
+		func(s []byte, source int) bool { strs = append(strs, string(s)); return true },
+		eb, exec, false, 0, false, true)
 
 	reader.startEventPoller()
 
@@ -66,3 +71,143 @@ func TestReadFromCommand(t *testing.T) {
 		t.Error("EvtReadFin should be set")
 	}
 }
+
+func TestFeedCSV(t *testing.T) {
+	strs := []string{}
+	eb := util.NewEventBox()
+	exec := util.NewExecutor("")
+	reader := NewReader(
+		func(s []byte, source int) bool { strs = append(strs, string(s)); return true },
+		eb, exec, false, ',', false, false)
+
+	reader.feed(strings.NewReader("a,\"b\nc\"\n\"d\"\"\ne\",f\ng\n"), 0)
+	if len(strs) != 3 || strs[0] != "a,\"b\nc\"" || strs[1] != "\"d\"\"\ne\",f" || strs[2] != "g" {
+		t.Errorf("%q", strs)
+	}
+
+	// A quote in an unquoted field is a literal character. The input is read
+	// one byte at a time to check that the state is kept across the chunks.
+	strs = strs[:0]
+	reader.feed(iotest.OneByteReader(strings.NewReader("12\" pipe,foo\nbar,\"baz\"\"\nqux\"\n\"\"\n")), 0)
+	if len(strs) != 3 || strs[0] != "12\" pipe,foo" || strs[1] != "bar,\"baz\"\"\nqux\"" || strs[2] != "\"\"" {
+		t.Errorf("%q", strs)
+	}
+}
+
+func TestFeedDecompress(t *testing.T) {
+	for _, name := range []string{"lines.txt", "lines.txt.gz", "lines.txt.bz2", "lines.txt.zst"} {
+		strs := []string{}
+		eb := util.NewEventBox()
+		exec := util.NewExecutor("")
+		reader := NewReader(
+			func(s []byte, source int) bool { strs = append(strs, string(s)); return true },
+			eb, exec, false, 0, true, false)
+
+		file, err := os.Open(filepath.Join("testdata", name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reader.feedInput(file) || reader.readError() != nil {
+			t.Errorf("%s: %v", name, reader.readError())
+		}
+		file.Close()
+		if len(strs) != 3 || strs[0] != "foo" || strs[1] != "bar" || strs[2] != "baz" {
+			t.Errorf("%s: %q", name, strs)
+		}
+	}
+}
+
+func TestFeedDecompressError(t *testing.T) {
+	for _, name := range []string{"lines.txt.gz", "lines.txt.bz2", "lines.txt.zst"} {
+		data, err := os.ReadFile(filepath.Join("testdata", name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		// Truncated stream, and corrupt header with the valid magic bytes
+		for _, input := range [][]byte{data[:len(data)-4], append(data[:4:4], "corrupt"...)} {
+			eb := util.NewEventBox()
+			exec := util.NewExecutor("")
+			reader := NewReader(
+				func(s []byte, source int) bool { return true },
+				eb, exec, false, 0, true, false)
+			if reader.feedInput(bytes.NewReader(input)) || reader.readError() == nil {
+				t.Errorf("%s: error not reported for %q", name, input)
+			}
+		}
+	}
+}
+
+func TestReadMappedDecompress(t *testing.T) {
+	strs := []string{}
+	eb := util.NewEventBox()
+	exec := util.NewExecutor("")
+	reader := NewReader(
+		func(s []byte, source int) bool { strs = append(strs, string(s)); return true },
+		eb, exec, false, 0, true, false)
+	if !reader.readMapped(filepath.Join("testdata", "lines.txt.zst")) {
+		t.Error("failed to read file")
+	}
+	if strings.Join(strs, ",") != "foo,bar,baz" {
+		t.Errorf("%q", strs)
+	}
+
+	// Files given as the walker roots are listed, not decompressed
+	strs = []string{}
+	roots := []string{filepath.Join("testdata", "lines.txt.gz")}
+	if !reader.readFiles(roots, walkerOpts{file: true}, nil) {
+		t.Error("failed to read files")
+	}
+	if len(strs) != 1 || strs[0] != roots[0] {
+		t.Errorf("%q", strs)
+	}
+}
+
+func TestReadMapped(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "input")
+	if err := os.WriteFile(path, []byte("foo\nbar\n\nbaz"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	slices := [][]byte{}
+	eb := util.NewEventBox()
+	exec := util.NewExecutor("")
+	reader := NewReader(
+		func(s []byte, source int) bool { slices = append(slices, s); return true },
+		eb, exec, false, 0, false, false)
+	if !reader.readMapped(path) {
+		t.Error("failed to read file")
+	}
+	strs := []string{}
+	for _, slice := range slices {
+		if cap(slice) != len(slice) {
+			t.Errorf("capacity should be limited: %q", slice)
+		}
+		strs = append(strs, string(slice))
+	}
+	if strings.Join(strs, ",") != "foo,bar,,baz" {
+		t.Errorf("%q", strs)
+	}
+
+	// Empty file
+	empty := filepath.Join(t.TempDir(), "empty")
+	os.WriteFile(empty, []byte{}, 0o600)
+	slices = slices[:0]
+	if !reader.readMapped(empty) || len(slices) != 0 {
+		t.Errorf("%q", slices)
+	}
+
+	// A quote in an unquoted CSV field does not start a quoted field
+	os.WriteFile(path, []byte("12\" pipe,foo\nbar,\"baz\nqux\"\n"), 0o600)
+	slices = slices[:0]
+	reader.csvSep = ','
+	if !reader.readMapped(path) || len(slices) != 2 ||
+		string(slices[0]) != "12\" pipe,foo" || string(slices[1]) != "bar,\"baz\nqux\"" {
+		t.Errorf("%q", slices)
+	}
+	reader.csvSep = 0
+
+	// Missing file
+	if reader.readMapped(filepath.Join(t.TempDir(), "missing")) {
+		t.Error("should fail")
+	}
+}
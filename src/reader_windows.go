@@ -0,0 +1,14 @@
+//go:build windows
+
+package fzf
+
+import (
+	"os"
+)
+
+// mapFile reads the whole file into a single buffer, as memory-mapping is not
+// supported on Windows yet. Items are still built from the slices of the
+// buffer without further copying.
+func mapFile(path string) ([]byte, error) {
+	return os.ReadFile(path)
+}
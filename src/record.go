@@ -0,0 +1,253 @@
+package fzf
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/junegunn/fzf/src/tui"
+	"github.com/junegunn/fzf/src/util"
+)
+
+// sessionEntry is a line of the file written by --record. The file is a
+// sequence of JSON objects, one per line.
+//
+//	{"type":"input","ms":0,"data":"foo"}
+//	{"type":"start","ms":3,"width":80,"height":24}
+//	{"type":"event","ms":812,"event":"Rune","char":102}
+//	{"type":"resize","ms":1500,"width":100,"height":30}
+//	{"type":"event","ms":2210,"event":"Enter"}
+//	{"type":"end","ms":2215}
+type sessionEntry struct {
+	Type   string          `json:"type"`
+	Time   int64           `json:"ms"` // Milliseconds since the start of the session
+	Width  int             `json:"width,omitempty"`
+	Height int             `json:"height,omitempty"`
+	Data   string          `json:"data,omitempty"`
+	Event  string          `json:"event,omitempty"`
+	Char   rune            `json:"char,omitempty"`
+	Mouse  *tui.MouseEvent `json:"mouse,omitempty"`
+}
+
+const (
+	sessionInput  = "input"
+	sessionStart  = "start"
+	sessionEvent  = "event"
+	sessionResize = "resize"
+	sessionEnd    = "end"
+)
+
+// sessionRecorder wraps the renderer to record the events that the terminal
+// receives, along with the input lines
+type sessionRecorder struct {
+	tui.Renderer
+	mutex     sync.Mutex
+	file      *os.File
+	writer    *bufio.Writer
+	start     time.Time
+	inputDone *util.AtomicBool
+	closed    bool
+}
+
+func newSessionRecorder(path string) (*sessionRecorder, error) {
+	// The recording can contain sensitive input
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return nil, err
+	}
+	return &sessionRecorder{file: file, writer: bufio.NewWriter(file), start: time.Now(), inputDone: util.NewAtomicBool(false)}, nil
+}
+
+func (r *sessionRecorder) write(entry sessionEntry, flush bool) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if r.closed {
+		return
+	}
+	entry.Time = time.Since(r.start).Milliseconds()
+	if bytes, err := json.Marshal(entry); err == nil {
+		r.writer.Write(bytes)
+		r.writer.WriteByte('\n')
+	}
+	if flush {
+		r.writer.Flush()
+	}
+}
+
+// recordInput records a line of the initial input. The lines read after
+// reload are not recorded as they are read again on replay.
+func (r *sessionRecorder) recordInput(data []byte) {
+	if !r.inputDone.Get() {
+		r.write(sessionEntry{Type: sessionInput, Data: string(data)}, false)
+	}
+}
+
+func (r *sessionRecorder) stopInput() {
+	r.inputDone.Set(true)
+}
+
+func (r *sessionRecorder) recordResize(size tui.TermSize) {
+	r.write(sessionEntry{Type: sessionResize, Width: size.Columns, Height: size.Lines}, true)
+}
+
+func (r *sessionRecorder) Init() error {
+	err := r.Renderer.Init()
+	size := r.Renderer.Size()
+	if size.Columns == 0 || size.Lines == 0 {
+		size.Columns = r.Renderer.MaxX()
+		size.Lines = r.Renderer.MaxY()
+	}
+	r.write(sessionEntry{Type: sessionStart, Width: size.Columns, Height: size.Lines}, true)
+	return err
+}
+
+func (r *sessionRecorder) GetChar(cancellable bool) tui.Event {
+	event := r.Renderer.GetChar(cancellable)
+	switch event.Type {
+	case tui.Invalid:
+	case tui.Resize:
+		r.recordResize(r.Renderer.Size())
+	default:
+		r.write(sessionEntry{Type: sessionEvent, Event: event.Type.String(), Char: event.Char, Mouse: event.MouseEvent}, true)
+	}
+	return event
+}
+
+func (r *sessionRecorder) Close() {
+	r.Renderer.Close()
+	r.write(sessionEntry{Type: sessionEnd}, true)
+	r.mutex.Lock()
+	if !r.closed {
+		r.closed = true
+		r.file.Close()
+	}
+	r.mutex.Unlock()
+}
+
+// sessionReplay is a renderer that replays the events recorded by --record
+// on the headless renderer
+type sessionReplay struct {
+	*tui.HeadlessRenderer
+	input  []string
+	events []sessionEntry
+	begin  int64 // Time of the start entry
+	end    int64
+	start  time.Time
+	cancel chan struct{}
+}
+
+func parseEventType(name string) (tui.EventType, bool) {
+	for eventType := tui.Rune; eventType <= tui.ResultFinal; eventType++ {
+		if eventType.String() == name {
+			return eventType, true
+		}
+	}
+	return 0, false
+}
+
+func loadSessionReplay(path string, tabstop int, maxHeightFunc func(int) int) (*sessionReplay, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	r := &sessionReplay{input: []string{}, cancel: make(chan struct{}, 1)}
+	width, height := 0, 0
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(nil, 64*1024*1024)
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		var entry sessionEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return nil, fmt.Errorf("invalid session file (line %d): %w", lineNum, err)
+		}
+		switch entry.Type {
+		case sessionInput:
+			r.input = append(r.input, entry.Data)
+		case sessionStart:
+			width, height = entry.Width, entry.Height
+			r.begin = entry.Time
+		case sessionEvent:
+			if _, ok := parseEventType(entry.Event); !ok {
+				return nil, fmt.Errorf("invalid session file (line %d): unknown event: %s", lineNum, entry.Event)
+			}
+			r.events = append(r.events, entry)
+		case sessionResize:
+			r.events = append(r.events, entry)
+		case sessionEnd:
+		default:
+			return nil, fmt.Errorf("invalid session file (line %d): unknown type: %s", lineNum, entry.Type)
+		}
+		r.end = max(r.end, entry.Time)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if width <= 0 || height <= 0 {
+		return nil, errors.New("invalid session file: terminal size not found")
+	}
+	r.HeadlessRenderer = tui.NewHeadlessRenderer(width, height, tabstop, maxHeightFunc)
+	return r, nil
+}
+
+// inputChan returns the channel that provides the recorded input lines
+func (r *sessionReplay) inputChan() chan string {
+	input := make(chan string)
+	go func() {
+		for _, line := range r.input {
+			input <- line
+		}
+		close(input)
+	}()
+	return input
+}
+
+func (r *sessionReplay) Init() error {
+	r.start = time.Now()
+	return nil
+}
+
+// GetChar waits until the time of the next recorded event and returns it.
+// When all events are replayed, it returns a fatal event at the end of the
+// session as if the terminal was closed.
+func (r *sessionReplay) GetChar(cancellable bool) tui.Event {
+	next := r.end
+	if len(r.events) > 0 {
+		next = r.events[0].Time
+	}
+	if wait := time.Until(r.start.Add(time.Duration(next-r.begin) * time.Millisecond)); wait > 0 {
+		timer := time.NewTimer(wait)
+		defer timer.Stop()
+		if cancellable {
+			select {
+			case <-timer.C:
+			case <-r.cancel:
+				return tui.Invalid.AsEvent()
+			}
+		} else {
+			<-timer.C
+		}
+	}
+	if len(r.events) == 0 {
+		return tui.Fatal.AsEvent()
+	}
+	entry := r.events[0]
+	r.events = r.events[1:]
+	if entry.Type == sessionResize {
+		r.SetSize(entry.Width, entry.Height)
+		return tui.Resize.AsEvent()
+	}
+	eventType, _ := parseEventType(entry.Event)
+	return tui.Event{Type: eventType, Char: entry.Char, MouseEvent: entry.Mouse}
+}
+
+func (r *sessionReplay) CancelGetChar() {
+	select {
+	case r.cancel <- struct{}{}:
+	default:
+	}
+}
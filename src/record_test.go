@@ -0,0 +1,98 @@
+package fzf
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/junegunn/fzf/src/tui"
+)
+
+// fakeRenderer returns the given events from GetChar
+type fakeRenderer struct {
+	*tui.HeadlessRenderer
+	events []tui.Event
+}
+
+func (r *fakeRenderer) GetChar(bool) tui.Event {
+	event := r.events[0]
+	r.events = r.events[1:]
+	return event
+}
+
+func TestRecordAndReplay(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "session")
+	recorder, err := newSessionRecorder(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	events := []tui.Event{
+		tui.Key('f'),
+		tui.CtrlA.AsEvent(),
+		tui.Invalid.AsEvent(),
+		{Type: tui.LeftClick, MouseEvent: &tui.MouseEvent{Y: 3, X: 5, Left: true, Down: true}},
+		tui.Enter.AsEvent(),
+	}
+	recorder.Renderer = &fakeRenderer{tui.NewHeadlessRenderer(80, 24, 8, nil), events}
+	recorder.recordInput([]byte("foo"))
+	recorder.recordInput([]byte(""))
+	recorder.Init()
+	recorder.recordInput([]byte("bar"))
+	recorder.stopInput()
+	recorder.recordInput([]byte("baz"))
+	for range events {
+		recorder.GetChar(true)
+	}
+	recorder.recordResize(tui.TermSize{Lines: 30, Columns: 100})
+	recorder.Close()
+
+	replay, err := loadSessionReplay(path, 8, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(replay.input, ",") != "foo,,bar" {
+		t.Errorf("unexpected input: %v", replay.input)
+	}
+	if size := replay.Size(); size.Columns != 80 || size.Lines != 24 {
+		t.Errorf("unexpected size: %v", size)
+	}
+
+	replay.Init()
+	expected := []tui.Event{events[0], events[1], events[3], events[4], tui.Resize.AsEvent(), tui.Fatal.AsEvent()}
+	for _, e := range expected {
+		actual := replay.GetChar(true)
+		if actual.Type != e.Type || actual.Char != e.Char ||
+			(actual.MouseEvent == nil) != (e.MouseEvent == nil) ||
+			actual.MouseEvent != nil && *actual.MouseEvent != *e.MouseEvent {
+			t.Errorf("expected %v, got %v", e, actual)
+		}
+	}
+	if size := replay.Size(); size.Columns != 100 || size.Lines != 30 {
+		t.Errorf("unexpected size after resize: %v", size)
+	}
+
+	var lines []string
+	for line := range replay.inputChan() {
+		lines = append(lines, line)
+	}
+	if len(lines) != 3 {
+		t.Errorf("unexpected input lines: %v", lines)
+	}
+}
+
+func TestLoadInvalidSession(t *testing.T) {
+	dir := t.TempDir()
+	for _, content := range []string{
+		"",
+		"foo\n",
+		`{"type":"foo","ms":0}`,
+		`{"type":"start","ms":0,"width":80,"height":24}` + "\n" + `{"type":"event","ms":0,"event":"Foo"}`,
+	} {
+		path := filepath.Join(dir, "session")
+		os.WriteFile(path, []byte(content), 0600)
+		if _, err := loadSessionReplay(path, 8, nil); err == nil {
+			t.Errorf("should fail to load: %q", content)
+		}
+	}
+}
@@ -0,0 +1,153 @@
+package fzf
+
+import (
+	"bufio"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const remoteTimeout = 10 * time.Second
+
+// remoteAddress returns the address of the server to connect to. If the
+// address is not given, it is taken from $FZF_SOCK or $FZF_PORT that fzf
+// exports to the child processes.
+func remoteAddress(addr listenAddress) (listenAddress, error) {
+	if len(addr.sock) > 0 || addr.port > 0 {
+		return addr, nil
+	}
+	if sock := os.Getenv("FZF_SOCK"); len(sock) > 0 {
+		return listenAddress{"", 0, sock}, nil
+	}
+	if port := os.Getenv("FZF_PORT"); len(port) > 0 {
+		return parseListenAddress(port)
+	}
+	return addr, errors.New("server address required (--remote=ADDR, $FZF_SOCK, or $FZF_PORT)")
+}
+
+func remoteRequest(actions []string, apiKey string) string {
+	var request strings.Builder
+	if len(actions) == 0 {
+		request.WriteString("GET / HTTP/1.1" + crlf)
+	} else {
+		request.WriteString("POST / HTTP/1.1" + crlf)
+	}
+	request.WriteString("Host: localhost" + crlf)
+	request.WriteString("Connection: close" + crlf)
+	if len(apiKey) > 0 {
+		request.WriteString("x-api-key: " + apiKey + crlf)
+	}
+	body := strings.Join(actions, "+")
+	if len(body) > 0 {
+		request.WriteString(fmt.Sprintf("Content-Length: %d%s", len(body), crlf))
+	}
+	request.WriteString(crlf + body)
+	return request.String()
+}
+
+// parseResponse returns the status code and the body of the response
+func parseResponse(reader *bufio.Reader) (int, string, error) {
+	status, err := reader.ReadString('\n')
+	if err != nil {
+		return 0, "", errors.New("failed to read response")
+	}
+	fields := strings.Fields(status)
+	if len(fields) < 2 || !strings.HasPrefix(fields[0], "HTTP/") {
+		return 0, "", errors.New("invalid response: " + strings.TrimSpace(status))
+	}
+	code, err := strconv.Atoi(fields[1])
+	if err != nil {
+		return 0, "", errors.New("invalid status code: " + fields[1])
+	}
+
+	length := -1
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return 0, "", errors.New("failed to read response headers")
+		}
+		if line == crlf {
+			break
+		}
+		pair := strings.SplitN(line, ":", 2)
+		if len(pair) == 2 && strings.ToLower(pair[0]) == "content-length" {
+			length, _ = strconv.Atoi(strings.TrimSpace(pair[1]))
+		}
+	}
+
+	var body []byte
+	if length >= 0 {
+		body = make([]byte, length)
+		_, err = io.ReadFull(reader, body)
+	} else {
+		body, err = io.ReadAll(reader)
+	}
+	if err != nil {
+		return 0, "", errors.New("failed to read response body")
+	}
+	return code, string(body), nil
+}
+
+// remoteDial connects to the server. TLS is used if $FZF_TLS_CERT is set,
+// which fzf exports to the child processes when started with --listen-tls.
+// The certificate is verified against $FZF_TLS_SERVER_NAME if set, as the
+// address of the server, usually localhost, may not be in the certificate.
+func remoteDial(addr listenAddress) (net.Conn, error) {
+	if len(addr.sock) > 0 {
+		return net.DialTimeout("unix", addr.sock, remoteTimeout)
+	}
+	address := net.JoinHostPort(addr.host, strconv.Itoa(addr.port))
+	if certFile := os.Getenv("FZF_TLS_CERT"); len(certFile) > 0 {
+		serverName := addr.host
+		if name := os.Getenv("FZF_TLS_SERVER_NAME"); len(name) > 0 {
+			serverName = name
+		}
+		config, err := clientTLSConfig(certFile, serverName)
+		if err != nil {
+			return nil, err
+		}
+		return tls.DialWithDialer(&net.Dialer{Timeout: remoteTimeout}, "tcp", address, config)
+	}
+	return net.DialTimeout("tcp", address, remoteTimeout)
+}
+
+// RunRemote sends the actions to the server of another fzf process started
+// with --listen option, or prints its state if no action is given.
+func RunRemote(opts *Options) (int, error) {
+	addr, err := remoteAddress(*opts.Remote)
+	if err != nil {
+		return ExitError, err
+	}
+
+	conn, err := remoteDial(addr)
+	if err != nil {
+		return ExitError, err
+	}
+	defer conn.Close()
+
+	conn.SetDeadline(time.Now().Add(remoteTimeout))
+	if _, err := conn.Write([]byte(remoteRequest(opts.RemoteActions, os.Getenv("FZF_API_KEY")))); err != nil {
+		return ExitError, err
+	}
+	code, body, err := parseResponse(bufio.NewReader(conn))
+	if err != nil {
+		return ExitError, err
+	}
+	if code >= 400 {
+		message := strings.TrimSpace(body)
+		if len(message) == 0 {
+			message = fmt.Sprintf("request failed with status %d", code)
+		}
+		return ExitError, errors.New(message)
+	}
+	if len(body) > 0 {
+		fmt.Println(strings.TrimSuffix(body, "\n"))
+	}
+	return ExitOk, nil
+}
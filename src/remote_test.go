@@ -0,0 +1,81 @@
+package fzf
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+)
+
+func TestParseRemoteOptions(t *testing.T) {
+	opts, err := ParseOptions(true, []string{"--multi", "--remote=6266", "change-query(--foo)", "up"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if opts.Remote == nil || opts.Remote.port != 6266 {
+		t.Errorf("invalid address: %v", opts.Remote)
+	}
+	if len(opts.RemoteActions) != 2 || opts.RemoteActions[0] != "change-query(--foo)" || opts.RemoteActions[1] != "up" {
+		t.Errorf("invalid actions: %v", opts.RemoteActions)
+	}
+
+	opts, _ = ParseOptions(true, []string{"--remote"})
+	if opts.Remote == nil || opts.Remote.port != 0 || len(opts.RemoteActions) != 0 {
+		t.Errorf("invalid options: %v, %v", opts.Remote, opts.RemoteActions)
+	}
+}
+
+func TestRemoteAddress(t *testing.T) {
+	t.Setenv("FZF_SOCK", "")
+	t.Setenv("FZF_PORT", "")
+	if _, err := remoteAddress(listenAddress{}); err == nil {
+		t.Error("should fail without address")
+	}
+	t.Setenv("FZF_PORT", "1234")
+	if addr, _ := remoteAddress(listenAddress{}); addr.host != "localhost" || addr.port != 1234 {
+		t.Errorf("invalid address: %v", addr)
+	}
+	t.Setenv("FZF_SOCK", "/tmp/fzf.sock")
+	if addr, _ := remoteAddress(listenAddress{}); addr.sock != "/tmp/fzf.sock" {
+		t.Errorf("invalid address: %v", addr)
+	}
+	if addr, _ := remoteAddress(listenAddress{"localhost", 5678, ""}); addr.port != 5678 {
+		t.Errorf("invalid address: %v", addr)
+	}
+}
+
+func TestRemoteRequest(t *testing.T) {
+	requests := make(chan serverRequest, 1)
+	listener, port := startTestServer(t, requests, func(getParams) string {
+		return `{"query":"foo"}`
+	})
+	defer listener.Close()
+
+	send := func(actions []string, apiKey string) (int, string) {
+		conn, err := net.Dial("tcp", fmt.Sprintf("localhost:%d", port))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer conn.Close()
+		conn.Write([]byte(remoteRequest(actions, apiKey)))
+		code, body, err := parseResponse(bufio.NewReader(conn))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return code, body
+	}
+
+	if code, body := send(nil, ""); code != 200 || body != `{"query":"foo"}`+"\n" {
+		t.Errorf("unexpected response: %d, %q", code, body)
+	}
+	if code, body := send([]string{"change-query(foo)", "up"}, ""); code != 200 || len(body) != 0 {
+		t.Errorf("unexpected response: %d, %q", code, body)
+	}
+	if request := <-requests; len(request.actions) != 2 || request.actions[0].a != "foo" || request.actions[1].t != actUp {
+		t.Errorf("unexpected request: %v", request.actions)
+	}
+	if code, body := send([]string{"foo"}, ""); code != 400 || !strings.Contains(body, "unknown action") {
+		t.Errorf("unexpected response: %d, %q", code, body)
+	}
+}
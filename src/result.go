@@ -29,7 +29,7 @@ type Result struct {
 	points [4]uint16
 }
 
-func buildResult(item *Item, offsets []Offset, score int) Result {
+func buildResult(item *Item, offsets []Offset, score int, sources *sourceTable) Result {
 	if len(offsets) > 1 {
 		slices.SortFunc(offsets, compareOffsets)
 	}
@@ -48,11 +48,11 @@ func buildResult(item *Item, offsets []Offset, score int) Result {
 		}
 	}
 
-	return buildResultFromBounds(item, score, minBegin, minEnd, maxEnd, validOffsetFound)
+	return buildResultFromBounds(item, score, minBegin, minEnd, maxEnd, validOffsetFound, sources)
 }
 
 // buildResultFromBounds builds a Result from pre-computed offset bounds.
-func buildResultFromBounds(item *Item, score int, minBegin, minEnd, maxEnd int, validOffsetFound bool) Result {
+func buildResultFromBounds(item *Item, score int, minBegin, minEnd, maxEnd int, validOffsetFound bool, sources *sourceTable) Result {
 	result := Result{item: item}
 	numChars := item.text.Length()
 
@@ -94,6 +94,9 @@ func buildResultFromBounds(item *Item, score int, minBegin, minEnd, maxEnd int,
 					val = util.AsUint16(minBegin - lastDelim)
 				}
 			}
+		case bySource:
+			// Lower priority value is better
+			val = util.AsUint16(sources.priority(item.Index()))
 		case byBegin, byEnd:
 			if validOffsetFound {
 				whitePrefixLen := 0
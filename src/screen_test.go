@@ -0,0 +1,284 @@
+package fzf
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/junegunn/fzf/src/tui"
+)
+
+// screenTest runs fzf on the headless renderer so that the tests can examine
+// the rendered screen
+type screenTest struct {
+	t        *testing.T
+	renderer *tui.HeadlessRenderer
+	output   chan string
+	exit     chan int
+}
+
+func startScreenTest(t *testing.T, width int, height int, input []string, args ...string) *screenTest {
+	t.Helper()
+	opts, err := ParseOptions(false, args)
+	if err != nil {
+		t.Fatal(err)
+	}
+	renderer := tui.NewHeadlessRenderer(width, height, opts.Tabstop, nil)
+	opts.renderer = renderer
+	opts.Output = make(chan string, 100)
+	st := &screenTest{t: t, renderer: renderer, output: opts.Output, exit: make(chan int, 1)}
+	if input != nil {
+		opts.Input = make(chan string)
+		go func() {
+			for _, line := range input {
+				opts.Input <- line
+			}
+			close(opts.Input)
+		}()
+	}
+	go func() {
+		code, _ := Run(opts)
+		st.exit <- code
+	}()
+	return st
+}
+
+func (st *screenTest) send(events ...tui.Event) {
+	st.renderer.Feed(events...)
+}
+
+func (st *screenTest) typeString(str string) {
+	for _, r := range str {
+		st.send(tui.Key(r))
+	}
+}
+
+// until waits until the screen satisfies the condition
+func (st *screenTest) until(cond func(string) bool) string {
+	st.t.Helper()
+	var screen string
+	for range 500 {
+		if screen = st.renderer.Snapshot(); cond(screen) {
+			return screen
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	st.t.Fatalf("timed out waiting for the screen:\n%s", screen)
+	return screen
+}
+
+// expect waits until the screen is identical to the expected text
+func (st *screenTest) expect(expected string) {
+	st.t.Helper()
+	expected = strings.TrimPrefix(expected, "\n")
+	var screen string
+	for range 500 {
+		if screen = st.renderer.Snapshot(); screen == expected {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	st.t.Fatalf("unexpected screen:\n%s\n\nexpected:\n%s", screen, expected)
+}
+
+// wait waits for fzf to exit and returns the exit code and the output
+func (st *screenTest) wait() (int, []string) {
+	st.t.Helper()
+	select {
+	case code := <-st.exit:
+		close(st.output)
+		lines := []string{}
+		for line := range st.output {
+			lines = append(lines, line)
+		}
+		return code, lines
+	case <-time.After(5 * time.Second):
+		st.t.Fatal("fzf did not exit")
+	}
+	return 0, nil
+}
+
+func TestScreenLayout(t *testing.T) {
+	for _, test := range []struct {
+		args     []string
+		initial  string
+		filtered string
+	}{
+		{nil, `
+
+
+
+▌ baz
+▌ bar
+▌ foo
+  3/3 ───────────────────────
+>`, `
+
+
+
+
+▌ baz
+▌ bar
+  2/3 ───────────────────────
+> ba`},
+		{[]string{"--reverse", "--border", "rounded", "--border-label", "Label"}, `
+╭───────────Label────────────╮
+│ >                          │
+│   3/3 ──────────────────── │
+│ ▌ foo                      │
+│ ▌ bar                      │
+│ ▌ baz                      │
+│                            │
+╰────────────────────────────╯`, `
+╭───────────Label────────────╮
+│ > ba                       │
+│   2/3 ──────────────────── │
+│ ▌ bar                      │
+│ ▌ baz                      │
+│                            │
+│                            │
+╰────────────────────────────╯`},
+		{[]string{"--layout", "reverse-list", "--list-border", "rounded", "--list-label", "List", "--input-border", "rounded", "--input-label", "Input"}, `
+╭────────────List────────────╮
+│ ▌ foo                     ││
+│ ▌ bar                      │
+╰────────────────────────────╯
+╭───────────Input────────────╮
+│   3/3                      │
+│ >                          │
+╰────────────────────────────╯`, `
+╭────────────List────────────╮
+│ ▌ bar                      │
+│ ▌ baz                      │
+╰────────────────────────────╯
+╭───────────Input────────────╮
+│   2/3                      │
+│ > ba                       │
+╰────────────────────────────╯`},
+	} {
+		st := startScreenTest(t, 30, 8, []string{"foo", "bar", "baz"}, test.args...)
+		st.expect(test.initial)
+		st.typeString("ba")
+		st.expect(test.filtered)
+		st.send(tui.Enter.AsEvent())
+		if code, output := st.wait(); code != ExitOk || strings.Join(output, ",") != "bar" {
+			t.Errorf("%v: unexpected result: %d, %v", test.args, code, output)
+		}
+	}
+}
+
+func TestScreenPreview(t *testing.T) {
+	for _, test := range []struct {
+		window   string
+		expected string
+	}{
+		{"right,50%,border-rounded", `
+               ╭─────────────╮
+               │ [bar]       │
+               │             │
+               │             │
+▌ baz          │             │
+▌ bar          │             │
+  2/3 ──────── │             │
+> ba           ╰─────────────╯`},
+		{"up,3,border-rounded", `
+╭────────────────────────────╮
+│ [bar]                  1/1 │
+╰────────────────────────────╯
+
+▌ baz
+▌ bar
+  2/3 ───────────────────────
+> ba`},
+	} {
+		st := startScreenTest(t, 30, 8, []string{"foo", "bar", "baz"}, "--preview", "echo [{}]", "--preview-window", test.window)
+		st.until(func(screen string) bool { return strings.Contains(screen, "[foo]") })
+		st.typeString("ba")
+		st.expect(test.expected)
+		st.send(tui.Esc.AsEvent())
+		if code, _ := st.wait(); code != ExitInterrupt {
+			t.Errorf("%s: unexpected exit code: %d", test.window, code)
+		}
+	}
+}
+
+func TestScreenColors(t *testing.T) {
+	st := startScreenTest(t, 30, 4, []string{"foo", "bar"}, "--reverse", "--query", "ar")
+	st.until(func(screen string) bool { return strings.Contains(screen, "1/2") })
+	for _, expected := range []struct {
+		x     int
+		text  string
+		color tui.ColorPair
+	}{
+		{0, "▌", tui.ColCurrentPointer},
+		{2, "b", tui.ColCurrent},
+		{3, "a", tui.ColCurrentMatch},
+		{4, "r", tui.ColCurrentMatch},
+	} {
+		if cell := st.renderer.Cell(2, expected.x); cell.Text != expected.text || cell.Color != expected.color {
+			t.Errorf("unexpected cell at %d: %v (expected: %q, %v)", expected.x, cell, expected.text, expected.color)
+		}
+	}
+	st.send(tui.Esc.AsEvent())
+	st.wait()
+}
+
+func TestScreenSources(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "a")
+	if err := os.WriteFile(file, []byte("a1\na2\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	st := startScreenTest(t, 30, 8, nil, "--multi", "--reverse", "--marker", "*",
+		"--source", "a=cat "+file, "--source", "b=echo b1", "--with-nth", "{src}:{..}",
+		"--bind", "ctrl-r:reload-source(a),ctrl-x:reload(echo x1)")
+	st.until(func(screen string) bool { return strings.Contains(screen, "3/3") })
+	st.typeString("b1")
+	st.until(func(screen string) bool { return strings.Contains(screen, "1/3") })
+	st.send(tui.Tab.AsEvent(), tui.CtrlU.AsEvent())
+	st.until(func(screen string) bool { return strings.Contains(screen, "3/3 (1)") })
+
+	// The selection should stay on the item from the other source
+	if err := os.WriteFile(file, []byte("a1\na2\na3\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	st.send(tui.CtrlR.AsEvent())
+	screen := st.until(func(screen string) bool { return strings.Contains(screen, "4/4 (1)") })
+	for _, line := range strings.Split(screen, "\n") {
+		if strings.Contains(line, "*") != strings.Contains(line, "b:b1") {
+			t.Errorf("unexpected selection:\n%s", screen)
+		}
+	}
+
+	// The output of a plain reload is not tagged
+	st.send(tui.CtrlX.AsEvent())
+	st.until(func(screen string) bool { return strings.Contains(screen, " :x1") })
+	st.send(tui.Esc.AsEvent())
+	st.wait()
+}
+
+func TestScreenDedupLast(t *testing.T) {
+	st := startScreenTest(t, 30, 8, []string{"foo", "bar", "foo", "baz", "bar"}, "--dedup", "--dedup-keep", "last", "--reverse")
+	st.until(func(screen string) bool {
+		return strings.Count(screen, "foo") == 1 && strings.Count(screen, "bar") == 1 &&
+			strings.Index(screen, "foo") < strings.Index(screen, "baz") &&
+			strings.Index(screen, "baz") < strings.Index(screen, "bar")
+	})
+	st.send(tui.Esc.AsEvent())
+	st.wait()
+}
+
+func TestScreenDedupLastTail(t *testing.T) {
+	lines := []string{}
+	for range 10 {
+		lines = append(lines, "foo", "bar")
+	}
+	st := startScreenTest(t, 30, 8, lines, "--dedup", "--dedup-keep", "last", "--tail", "4", "--reverse")
+	st.until(func(screen string) bool {
+		return strings.Count(screen, "foo") == 1 && strings.Count(screen, "bar") == 1 &&
+			strings.Index(screen, "foo") < strings.Index(screen, "bar")
+	})
+	st.send(tui.Esc.AsEvent())
+	st.wait()
+}
@@ -0,0 +1,86 @@
+package fzf
+
+import (
+	"errors"
+	neturl "net/url"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/junegunn/fzf/src/util"
+)
+
+var errSearchTimeout = errors.New("timeout")
+var errSearchCancelled = errors.New("cancelled")
+
+// searchParams is the parameters of a headless search request
+type searchParams struct {
+	query   string
+	id      string
+	limit   int
+	offset  int
+	timeout time.Duration
+}
+
+// headlessSearch is a request to search the current list without affecting
+// the interactive search. The result is sent to the channel, or nil if the
+// search is cancelled.
+type headlessSearch struct {
+	query     []rune
+	cancelled *util.AtomicBool
+	result    chan *Merger
+}
+
+// SearchResult is the response of a headless search
+type SearchResult struct {
+	Query      string       `json:"query"`
+	MatchCount int          `json:"matchCount"`
+	Matches    []StatusItem `json:"matches"`
+}
+
+func parseSearchParams(query string) (searchParams, error) {
+	params := searchParams{limit: 100, timeout: syncTimeout}
+	values, err := neturl.ParseQuery(query)
+	if err != nil {
+		return params, errors.New("invalid query string")
+	}
+	params.query = values.Get("q")
+	params.id = values.Get("id")
+	for name, target := range map[string]*int{"limit": &params.limit, "offset": &params.offset} {
+		if str := values.Get(name); len(str) > 0 {
+			val, err := strconv.Atoi(str)
+			if err != nil || val < 0 {
+				return params, errors.New("invalid " + name + ": " + str)
+			}
+			*target = val
+		}
+	}
+	if str := values.Get("timeout"); len(str) > 0 {
+		val, err := strconv.Atoi(str)
+		if err != nil || val <= 0 {
+			return params, errors.New("invalid timeout: " + str)
+		}
+		params.timeout = min(time.Duration(val)*time.Millisecond, maxSyncTimeout)
+	}
+	return params, nil
+}
+
+// searchItems returns the items in the given range of the merger along with
+// their scores and the positions of the matched characters
+func searchItems(merger *Merger, offset int, limit int, ansi bool) []StatusItem {
+	slab := util.MakeSlab(slab16Size, slab32Size)
+	items := make([]StatusItem, max(0, min(limit, merger.Length()-offset)))
+	for i := range items {
+		item := merger.Get(i + offset).item
+		items[i] = StatusItem{Index: int(item.Index()), Text: item.AsString(ansi)}
+		if merger.pattern != nil {
+			_, _, pos, score := merger.pattern.matchItem(item, true, slab)
+			if pos != nil {
+				sort.Ints(*pos)
+				items[i].Positions = *pos
+			}
+			items[i].Score = &score
+		}
+	}
+	return items
+}
@@ -0,0 +1,101 @@
+package fzf
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/junegunn/fzf/src/util"
+)
+
+func TestParseSearchParams(t *testing.T) {
+	params, err := parseSearchParams("q=foo%20bar&limit=10&offset=5&id=x")
+	if err != nil || params.query != "foo bar" || params.limit != 10 || params.offset != 5 || params.id != "x" || params.timeout != syncTimeout {
+		t.Errorf("invalid params: %v, %v", params, err)
+	}
+	params, _ = parseSearchParams("q=%5Efoo+%21bar&timeout=999999999")
+	if params.query != "^foo !bar" || params.timeout != maxSyncTimeout || params.limit != 100 {
+		t.Errorf("invalid params: %v", params)
+	}
+	for _, query := range []string{"limit=-1", "offset=foo", "timeout=0", "q=%zz"} {
+		if _, err := parseSearchParams(query); err == nil {
+			t.Errorf("should fail: %s", query)
+		}
+	}
+}
+
+func TestMatcherSearch(t *testing.T) {
+	sortCriteria = []criterion{byScore, byLength}
+
+	cache := NewChunkCache()
+	cl := NewChunkList(cache, func(item *Item, s []byte, source int) bool {
+		item.text = util.ToChars(s)
+		return true
+	})
+	for i := range 1000 {
+		cl.Push(fmt.Appendf(nil, "item %d", i), 0)
+	}
+	snapshot, _, _ := cl.Snapshot(0)
+	matcher := NewMatcher(cache, nil, true, false, util.NewEventBox(), revision{}, 4)
+
+	merger := matcher.Search(snapshot, buildPatternWith(cache, []rune("'99")), true, util.NewAtomicBool(false))
+	// 99, 199, ..., 999, 990 ~ 998
+	if merger.Length() != 19 {
+		t.Errorf("unexpected number of matches: %d", merger.Length())
+	}
+	if text := merger.Get(0).item.text.ToString(); text != "item 99" {
+		t.Errorf("unexpected first match: %s", text)
+	}
+
+	items := searchItems(merger, 1, 2, false)
+	if len(items) != 2 || items[0].Score == nil || len(items[0].Positions) != 2 {
+		t.Errorf("unexpected items: %v", items)
+	}
+
+	if merger := matcher.Search(snapshot, buildPatternWith(cache, []rune{}), true, util.NewAtomicBool(false)); merger.Length() != 1000 {
+		t.Errorf("unexpected number of items: %d", merger.Length())
+	}
+	if merger := matcher.Search(snapshot, buildPatternWith(cache, []rune("item")), true, util.NewAtomicBool(true)); merger != nil {
+		t.Error("cancelled search should return nil")
+	}
+	if merger := matcher.Search(nil, buildPatternWith(cache, []rune("item")), true, util.NewAtomicBool(false)); merger.Length() != 0 {
+		t.Error("should be empty")
+	}
+}
+
+func TestSearchEndpoint(t *testing.T) {
+	release := make(chan struct{})
+	listener, port := startTestServerWithHandlers(t, make(chan serverRequest), func(getParams) string {
+		return "{}"
+	}, nil, func(params searchParams) (string, error) {
+		if params.query == "slow" {
+			select {
+			case <-release:
+				return "", errSearchCancelled
+			case <-time.After(params.timeout):
+				return "", errSearchTimeout
+			}
+		}
+		return fmt.Sprintf(`{"query":%q,"limit":%d}`, params.query, params.limit), nil
+	}, nil)
+	defer listener.Close()
+
+	response := httpRequest(t, port, "GET /search?q=foo%20bar&limit=3 HTTP/1.1\r\nConnection: close\r\n\r\n")
+	if !strings.HasPrefix(response, httpOk) || !strings.HasSuffix(response, `{"query":"foo bar","limit":3}`+"\n") {
+		t.Errorf("unexpected response: %q", response)
+	}
+	response = httpRequest(t, port, "GET /search?q=slow&timeout=100 HTTP/1.1\r\nConnection: close\r\n\r\n")
+	if !strings.HasPrefix(response, httpUnavailable) || !strings.HasSuffix(response, `{"error":"timeout"}`+"\n") {
+		t.Errorf("unexpected response: %q", response)
+	}
+	close(release)
+	response = httpRequest(t, port, "GET /search?q=slow HTTP/1.1\r\nConnection: close\r\n\r\n")
+	if !strings.HasSuffix(response, `{"error":"cancelled"}`+"\n") {
+		t.Errorf("unexpected response: %q", response)
+	}
+	response = httpRequest(t, port, "GET /search?limit=foo HTTP/1.1\r\nConnection: close\r\n\r\n")
+	if !strings.HasPrefix(response, httpBadRequest) {
+		t.Errorf("unexpected response: %q", response)
+	}
+}
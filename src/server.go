@@ -2,27 +2,69 @@ package fzf
 
 import (
 	"bufio"
-	"bytes"
 	"crypto/subtle"
+	"crypto/tls"
 	"errors"
 	"fmt"
+	"io"
 	"net"
 	"os"
 	"regexp"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
 )
 
 var getRegex *regexp.Regexp
+var postRegex *regexp.Regexp
+var eventsRegex *regexp.Regexp
+var itemsRegex *regexp.Regexp
+var searchRegex *regexp.Regexp
+var configRegex *regexp.Regexp
 
 func init() {
-	getRegex = regexp.MustCompile(`^GET /(?:\?([a-z0-9=&]+))? HTTP`)
+	getRegex = regexp.MustCompile(`^GET /(?:\?([a-z0-9=&_,]+))? HTTP`)
+	postRegex = regexp.MustCompile(`^POST /(?:\?([a-z0-9=&_,]+))? HTTP`)
+	itemsRegex = regexp.MustCompile(`^POST /items/([a-z]+) HTTP`)
+	searchRegex = regexp.MustCompile(`^GET /search(?:\?(\S*))? HTTP`)
+	configRegex = regexp.MustCompile(`^GET /config HTTP`)
+	eventsRegex = regexp.MustCompile(`^GET /events(?:\?([a-z0-9=&,]+))? HTTP`)
 }
 
 type getParams struct {
-	limit  int
-	offset int
+	limit        int
+	offset       int
+	fields       map[string]bool
+	selectedOnly bool
+	sync         bool
+	timeout      time.Duration
+}
+
+// Optional fields of the status that can be requested with fields parameter
+var statusFieldNames = []string{"score", "display", "output", "selected", "header", "prompt", "nth"}
+
+// serverRequest is a list of actions sent to the terminal. If done is not
+// nil, it is closed when the actions take effect.
+type serverRequest struct {
+	actions []*action
+	done    chan struct{}
+}
+
+// apiScope determines the requests allowed with an API key
+type apiScope int
+
+const (
+	scopeStatus apiScope = iota // Read-only access to the state
+	scopeSafe                   // Actions that do not start processes
+	scopeFull
+)
+
+var apiScopeNames = map[string]apiScope{"status": scopeStatus, "safe": scopeSafe, "full": scopeFull}
+
+type apiKey struct {
+	key   []byte
+	scope apiScope
 }
 
 const (
@@ -30,17 +72,28 @@ const (
 	httpOk           = "HTTP/1.1 200 OK" + crlf
 	httpBadRequest   = "HTTP/1.1 400 Bad Request" + crlf
 	httpUnauthorized = "HTTP/1.1 401 Unauthorized" + crlf
+	httpForbidden    = "HTTP/1.1 403 Forbidden" + crlf
 	httpUnavailable  = "HTTP/1.1 503 Service Unavailable" + crlf
 	httpReadTimeout  = 10 * time.Second
+	httpWriteTimeout = 10 * time.Second
+	httpMaxWorkers   = 16
+	streamHeartbeat  = 15 * time.Second
 	channelTimeout   = 2 * time.Second
+	syncTimeout      = 5 * time.Second
+	maxSyncTimeout   = 60 * time.Second
 	jsonContentType  = "Content-Type: application/json" + crlf
+	eventContentType = "Content-Type: text/event-stream" + crlf
 	maxContentLength = 1024 * 1024
 )
 
 type httpServer struct {
-	apiKey        []byte
-	actionChannel chan []*action
+	apiKeys       []apiKey
+	actionChannel chan serverRequest
 	getHandler    func(getParams) string
+	eventStream   *eventStream
+	itemHandler   func(string, []byte) error
+	searchHandler func(searchParams) (string, error)
+	configHandler func() string
 }
 
 type listenAddress struct {
@@ -78,16 +131,22 @@ func parseListenAddress(address string) (listenAddress, error) {
 	return listenAddress{parts[0], port, ""}, nil
 }
 
-func startHttpServer(address listenAddress, actionChannel chan []*action, getHandler func(getParams) string) (net.Listener, int, error) {
+func startHttpServer(address listenAddress, tlsConfig *tls.Config, actionChannel chan serverRequest, getHandler func(getParams) string, eventStream *eventStream, itemHandler func(string, []byte) error, searchHandler func(searchParams) (string, error), configHandler func() string) (net.Listener, int, error) {
 	host := address.host
 	port := address.port
-	apiKey := os.Getenv("FZF_API_KEY")
-	if !address.IsLocal() && len(apiKey) == 0 {
+	apiKeys, err := parseApiKeys(os.Getenv("FZF_API_KEY"), os.Getenv("FZF_API_KEYS"))
+	if err != nil {
+		return nil, port, err
+	}
+	if !address.IsLocal() && len(apiKeys) == 0 {
 		return nil, port, errors.New("FZF_API_KEY is required to allow remote access")
 	}
+	if !address.IsLocal() && tlsConfig == nil {
+		// Do not send the API key in cleartext over the network
+		return nil, port, errors.New("--listen-tls is required for a non-localhost listen address, or the API key would be sent in cleartext")
+	}
 
 	var listener net.Listener
-	var err error
 	if len(address.sock) > 0 {
 		if _, err := os.Stat(address.sock); err == nil {
 			// Check if the socket is already in use
@@ -122,12 +181,22 @@ func startHttpServer(address listenAddress, actionChannel chan []*action, getHan
 		}
 	}
 
+	if tlsConfig != nil {
+		// Handshake is performed on the first read or write of each connection
+		listener = tls.NewListener(listener, tlsConfig)
+	}
+
 	go func() {
 		server := httpServer{
-			apiKey:        []byte(apiKey),
+			apiKeys:       apiKeys,
 			actionChannel: actionChannel,
 			getHandler:    getHandler,
+			eventStream:   eventStream,
+			itemHandler:   itemHandler,
+			searchHandler: searchHandler,
+			configHandler: configHandler,
 		}
+		workers := make(chan struct{}, httpMaxWorkers)
 		for {
 			conn, err := listener.Accept()
 			if err != nil {
@@ -136,132 +205,399 @@ func startHttpServer(address listenAddress, actionChannel chan []*action, getHan
 				}
 				continue
 			}
-			conn.Write([]byte(server.handleHttpRequest(conn)))
-			conn.Close()
+			if acquireWorker(conn, workers) {
+				go server.serve(conn, workers)
+			}
 		}
 	}()
 
 	return listener, port, nil
 }
 
+// acquireWorker takes a worker from the pool. If there is none available, it
+// rejects the connection immediately instead of making the client wait.
+func acquireWorker(conn net.Conn, workers chan struct{}) bool {
+	select {
+	case workers <- struct{}{}:
+		return true
+	default:
+		conn.SetWriteDeadline(time.Now().Add(httpWriteTimeout))
+		conn.Write([]byte(httpUnavailable + "Connection: close" + crlf + "Content-Length: 0" + crlf + crlf))
+		conn.Close()
+		return false
+	}
+}
+
+// serve handles the requests on the connection until the client closes it or
+// asks to close it. The caller should have acquired a worker for the first
+// request. The worker is returned to the pool while the connection is idle.
+func (server *httpServer) serve(conn net.Conn, workers chan struct{}) {
+	release := func() { <-workers }
+	reader := bufio.NewReader(conn)
+	for {
+		conn.SetReadDeadline(time.Now().Add(httpReadTimeout))
+		response, sub, keepAlive := server.handleHttpRequest(reader)
+		if len(response) == 0 {
+			// Connection closed by the client
+			break
+		}
+		if !keepAlive && sub == nil {
+			response = strings.Replace(response, crlf, crlf+"Connection: close"+crlf, 1)
+		}
+		conn.SetWriteDeadline(time.Now().Add(httpWriteTimeout))
+		if _, err := conn.Write([]byte(response)); err != nil {
+			break
+		}
+		if sub != nil {
+			// Event stream does not occupy a worker
+			release()
+			server.streamEvents(conn, sub)
+			return
+		}
+		if !keepAlive {
+			break
+		}
+
+		// Wait for the next request without holding the worker
+		release()
+		conn.SetReadDeadline(time.Now().Add(httpReadTimeout))
+		if _, err := reader.Peek(1); err != nil {
+			conn.Close()
+			return
+		}
+		if !acquireWorker(conn, workers) {
+			return
+		}
+	}
+	conn.Close()
+	release()
+}
+
 // Here we are writing a simplistic HTTP server without using net/http
 // package to reduce the size of the binary.
 //
 // * No --listen:            2.8MB
 // * --listen with net/http: 5.7MB
 // * --listen w/o net/http:  3.3MB
-func (server *httpServer) handleHttpRequest(conn net.Conn) string {
+//
+// handleHttpRequest reads a request from the reader and returns the response.
+// It also returns whether the connection can be reused for the next request.
+// An empty response is returned if the connection is closed before a request.
+func (server *httpServer) handleHttpRequest(reader *bufio.Reader) (string, *eventSubscriber, bool) {
 	contentLength := 0
 	apiKey := ""
-	var bodyBuilder strings.Builder
 	answer := func(code string, message string) string {
 		message += "\n"
 		return code + fmt.Sprintf("Content-Length: %d%s", len(message), crlf+crlf+message)
 	}
+	empty := func(code string) string {
+		return code + "Content-Length: 0" + crlf + crlf
+	}
 	unauthorized := func(message string) string {
 		return answer(httpUnauthorized, message)
 	}
+	forbidden := func(message string) string {
+		return answer(httpForbidden, message)
+	}
 	bad := func(message string) string {
 		return answer(httpBadRequest, message)
 	}
 	good := func(message string) string {
 		return answer(httpOk+jsonContentType, message)
 	}
-	conn.SetReadDeadline(time.Now().Add(httpReadTimeout))
-	scanner := bufio.NewScanner(conn)
-	scanner.Split(func(data []byte, atEOF bool) (int, []byte, error) {
-		found := bytes.Index(data, []byte(crlf))
-		if found >= 0 {
-			token := data[:found+len(crlf)]
-			return len(token), token, nil
-		}
-		if atEOF || bodyBuilder.Len()+len(data) >= contentLength {
-			return 0, data, bufio.ErrFinalToken
-		}
-		return 0, nil, nil
-	})
-
-	section := 0
-	var getMatch []string
-Loop:
-	for scanner.Scan() {
-		text := scanner.Text()
-		switch section {
-		case 0: // Request line
-			getMatch = getRegex.FindStringSubmatch(text)
-			if len(getMatch) == 0 && !strings.HasPrefix(text, "POST / HTTP") {
-				return bad("invalid request method")
-			}
-			section++
-		case 1: // Request headers
-			if text == crlf { // End of headers
-				if len(getMatch) > 0 {
-					break Loop
-				}
-				if contentLength == 0 {
-					return bad("content-length header missing")
+	readLine := func() (string, error) {
+		line, err := reader.ReadSlice('\n')
+		return strings.TrimRight(string(line), "\r\n"), err
+	}
+
+	// Request line
+	text, err := readLine()
+	if err != nil {
+		if len(text) == 0 && (errors.Is(err, io.EOF) || errors.Is(err, os.ErrDeadlineExceeded)) {
+			// Closed or idle connection
+			return "", nil, false
+		}
+		return bad("invalid request"), nil, false
+	}
+	getMatch := getRegex.FindStringSubmatch(text)
+	postMatch := postRegex.FindStringSubmatch(text)
+	itemsMatch := itemsRegex.FindStringSubmatch(text)
+	eventsMatch := eventsRegex.FindStringSubmatch(text)
+	searchMatch := searchRegex.FindStringSubmatch(text)
+	configMatch := configRegex.MatchString(text)
+	if len(getMatch) == 0 && len(postMatch) == 0 && len(itemsMatch) == 0 && len(eventsMatch) == 0 && len(searchMatch) == 0 && !configMatch {
+		return bad("invalid request method"), nil, false
+	}
+	// HTTP/1.1 connections are persistent by default
+	keepAlive := strings.HasSuffix(text, "HTTP/1.1")
+
+	// Request headers
+	for {
+		text, err := readLine()
+		if err != nil {
+			return bad("invalid request headers"), nil, false
+		}
+		if len(text) == 0 { // End of headers
+			break
+		}
+		pair := strings.SplitN(text, ":", 2)
+		if len(pair) == 2 {
+			value := strings.TrimSpace(pair[1])
+			switch strings.ToLower(pair[0]) {
+			case "content-length":
+				length, err := strconv.Atoi(value)
+				if err != nil || length <= 0 || length > maxContentLength {
+					return bad("invalid content length"), nil, false
 				}
-				section++
-				continue
-			}
-			pair := strings.SplitN(text, ":", 2)
-			if len(pair) == 2 {
-				switch strings.ToLower(pair[0]) {
-				case "content-length":
-					length, err := strconv.Atoi(strings.TrimSpace(pair[1]))
-					if err != nil || length <= 0 || length > maxContentLength {
-						return bad("invalid content length")
-					}
-					contentLength = length
-				case "x-api-key":
-					apiKey = strings.TrimSpace(pair[1])
+				contentLength = length
+			case "connection":
+				switch strings.ToLower(value) {
+				case "close":
+					keepAlive = false
+				case "keep-alive":
+					keepAlive = true
 				}
+			case "x-api-key":
+				apiKey = value
 			}
-		case 2: // Request body
-			bodyBuilder.WriteString(text)
 		}
 	}
+	if (len(postMatch) > 0 || len(itemsMatch) > 0) && contentLength == 0 {
+		return bad("content-length header missing"), nil, false
+	}
 
-	if len(server.apiKey) != 0 && subtle.ConstantTimeCompare([]byte(apiKey), server.apiKey) != 1 {
-		return unauthorized("invalid api key")
+	// Request body
+	body := make([]byte, contentLength)
+	if _, err := io.ReadFull(reader, body); err != nil {
+		return bad("incomplete request"), nil, false
+	}
+
+	scope, valid := server.authorize(apiKey)
+	if !valid {
+		return unauthorized("invalid api key"), nil, keepAlive
+	}
+	if scope == scopeStatus && (len(postMatch) > 0 || len(itemsMatch) > 0) {
+		return forbidden("forbidden: api key only allows reading the status"), nil, keepAlive
+	}
+
+	if len(eventsMatch) > 0 {
+		events, err := parseStreamEvents(parseEventsParam(eventsMatch[1]))
+		if err != nil {
+			return bad(err.Error()), nil, keepAlive
+		}
+		sub := server.eventStream.subscribe(events)
+		if sub == nil {
+			return empty(httpUnavailable), nil, false
+		}
+		return httpOk + eventContentType + "Cache-Control: no-cache" + crlf + crlf, sub, false
 	}
 
 	if len(getMatch) > 0 {
-		response := server.getHandler(parseGetParams(getMatch[1]))
+		params, err := parseGetParams(getMatch[1])
+		if err != nil {
+			return bad(err.Error()), nil, keepAlive
+		}
+		response := server.getHandler(params)
 		if len(response) > 0 {
-			return good(response)
+			return good(response), nil, keepAlive
 		}
-		return answer(httpUnavailable+jsonContentType, `{"error":"timeout"}`)
+		return answer(httpUnavailable+jsonContentType, `{"error":"timeout"}`), nil, keepAlive
 	}
 
-	body := bodyBuilder.String()
-	if len(body) < contentLength {
-		return bad("incomplete request")
+	if configMatch {
+		if server.configHandler == nil {
+			return empty(httpUnavailable), nil, keepAlive
+		}
+		if response := server.configHandler(); len(response) > 0 {
+			return good(response), nil, keepAlive
+		}
+		return answer(httpUnavailable+jsonContentType, `{"error":"timeout"}`), nil, keepAlive
 	}
-	body = body[:contentLength]
 
+	if len(searchMatch) > 0 {
+		if server.searchHandler == nil {
+			return empty(httpUnavailable), nil, keepAlive
+		}
+		params, err := parseSearchParams(searchMatch[1])
+		if err != nil {
+			return bad(err.Error()), nil, keepAlive
+		}
+		response, err := server.searchHandler(params)
+		if err != nil {
+			return answer(httpUnavailable+jsonContentType, fmt.Sprintf(`{"error":%q}`, err.Error())), nil, keepAlive
+		}
+		return good(response), nil, keepAlive
+	}
+
+	if len(itemsMatch) > 0 {
+		if server.itemHandler == nil {
+			return empty(httpUnavailable), nil, keepAlive
+		}
+		if err := server.itemHandler(itemsMatch[1], body); err != nil {
+			return bad(err.Error()), nil, keepAlive
+		}
+		return empty(httpOk), nil, keepAlive
+	}
+
+	params, err := parseGetParams(postMatch[1])
+	if err != nil {
+		return bad(err.Error()), nil, keepAlive
+	}
 	actions, err := parseSingleActionList(strings.Trim(string(body), "\r\n"), false)
 	if err != nil {
-		return bad(err.Error())
+		return bad(err.Error()), nil, keepAlive
 	}
 	if len(actions) == 0 {
-		return bad("no action specified")
+		return bad("no action specified"), nil, keepAlive
+	}
+	if scope == scopeSafe {
+		for _, action := range actions {
+			if !safeAction(action.t) {
+				return forbidden(fmt.Sprintf("forbidden: %s action requires full access", action.t.Name())), nil, keepAlive
+			}
+		}
 	}
 
+	var done chan struct{}
+	if params.sync {
+		done = make(chan struct{})
+	}
 	select {
-	case server.actionChannel <- actions:
+	case server.actionChannel <- serverRequest{actions, done}:
 	case <-time.After(channelTimeout):
-		return httpUnavailable + crlf
+		return empty(httpUnavailable), nil, keepAlive
+	}
+	if done == nil {
+		return empty(httpOk), nil, keepAlive
+	}
+
+	// Wait until the actions are applied and the search is complete
+	select {
+	case <-done:
+	case <-time.After(params.timeout):
+		return answer(httpUnavailable+jsonContentType, `{"error":"timeout"}`), nil, keepAlive
+	}
+	if response := server.getHandler(params); len(response) > 0 {
+		return good(response), nil, keepAlive
+	}
+	return answer(httpUnavailable+jsonContentType, `{"error":"timeout"}`), nil, keepAlive
+}
+
+// authorize returns the scope of the API key, or false if the key is invalid.
+// Every key is compared so that the time taken does not reveal which one
+// matched.
+func (server *httpServer) authorize(key string) (apiScope, bool) {
+	if len(server.apiKeys) == 0 {
+		return scopeFull, true
+	}
+	scope := scopeStatus
+	valid := false
+	for _, apiKey := range server.apiKeys {
+		if subtle.ConstantTimeCompare([]byte(key), apiKey.key) == 1 {
+			scope = max(scope, apiKey.scope)
+			valid = true
+		}
 	}
-	return httpOk + crlf
+	return scope, valid
 }
 
-func parseGetParams(query string) getParams {
-	params := getParams{limit: 100, offset: 0}
+// safeAction returns true if the action can be performed with a key of the
+// safe scope. Actions that start processes are not allowed, nor are trigger
+// and the macro actions which can perform any other actions.
+func safeAction(action actionType) bool {
+	return !processExecution(action) && !macroAction(action) && action != actTrigger
+}
+
+// parseApiKeys returns the list of API keys. FZF_API_KEY has the full access,
+// and FZF_API_KEYS is a comma-separated list of SCOPE:KEY pairs.
+func parseApiKeys(fullKey string, scopedKeys string) ([]apiKey, error) {
+	apiKeys := []apiKey{}
+	if len(fullKey) > 0 {
+		apiKeys = append(apiKeys, apiKey{[]byte(fullKey), scopeFull})
+	}
+	for _, pair := range strings.Split(scopedKeys, ",") {
+		pair = strings.TrimSpace(pair)
+		if len(pair) == 0 {
+			continue
+		}
+		name, key, found := strings.Cut(pair, ":")
+		scope, valid := apiScopeNames[name]
+		if !found || !valid || len(key) == 0 {
+			return nil, errors.New("invalid FZF_API_KEYS (expected: status|safe|full:KEY,...)")
+		}
+		apiKeys = append(apiKeys, apiKey{[]byte(key), scope})
+	}
+	return apiKeys, nil
+}
+
+// streamEvents writes the events to the client until the stream is closed or
+// the client is disconnected
+func (server *httpServer) streamEvents(conn net.Conn, sub *eventSubscriber) {
+	defer conn.Close()
+	defer server.eventStream.unsubscribe(sub)
+
+	heartbeat := time.NewTicker(streamHeartbeat)
+	defer heartbeat.Stop()
+	for {
+		var message []byte
+		select {
+		case msg, ok := <-sub.channel:
+			if !ok {
+				if sub.lagged {
+					// Let the client know why it is disconnected
+					conn.SetWriteDeadline(time.Now().Add(httpWriteTimeout))
+					conn.Write(streamLagMessage)
+				}
+				return
+			}
+			message = msg
+		case <-heartbeat.C:
+			message = []byte(":\n\n")
+		}
+		conn.SetWriteDeadline(time.Now().Add(httpWriteTimeout))
+		if _, err := conn.Write(message); err != nil {
+			return
+		}
+	}
+}
+
+func parseEventsParam(query string) string {
+	for _, pair := range strings.Split(query, "&") {
+		parts := strings.SplitN(pair, "=", 2)
+		if len(parts) == 2 && parts[0] == "events" {
+			return parts[1]
+		}
+	}
+	return ""
+}
+
+func parseGetParams(query string) (getParams, error) {
+	params := getParams{limit: 100, offset: 0, timeout: syncTimeout}
 	for _, pair := range strings.Split(query, "&") {
 		parts := strings.SplitN(pair, "=", 2)
+		switch parts[0] {
+		case "sync", "selected_only":
+			// Flags can be given without a value
+			enabled := len(parts) == 1 || parts[1] != "0" && parts[1] != "false"
+			if parts[0] == "sync" {
+				params.sync = enabled
+			} else {
+				params.selectedOnly = enabled
+			}
+			continue
+		}
 		if len(parts) == 2 {
 			switch parts[0] {
+			case "fields":
+				fields, err := parseStatusFields(parts[1])
+				if err != nil {
+					return params, err
+				}
+				params.fields = fields
+			case "timeout":
+				if val, err := strconv.Atoi(parts[1]); err == nil && val > 0 {
+					params.timeout = min(time.Duration(val)*time.Millisecond, maxSyncTimeout)
+				}
 			case "limit", "offset":
 				if val, err := strconv.Atoi(parts[1]); err == nil {
 					if parts[0] == "limit" {
@@ -273,5 +609,16 @@ func parseGetParams(query string) getParams {
 			}
 		}
 	}
-	return params
+	return params, nil
+}
+
+func parseStatusFields(str string) (map[string]bool, error) {
+	fields := make(map[string]bool)
+	for _, name := range strings.Split(str, ",") {
+		if !slices.Contains(statusFieldNames, name) {
+			return nil, errors.New("invalid field name: " + name)
+		}
+		fields[name] = true
+	}
+	return fields, nil
 }
@@ -0,0 +1,335 @@
+package fzf
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func httpRequest(t *testing.T, port int, request string) string {
+	conn, err := net.Dial("tcp", fmt.Sprintf("localhost:%d", port))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	conn.Write([]byte(request))
+	response, _ := io.ReadAll(conn)
+	return string(response)
+}
+
+func postRequest(path string, body string) string {
+	return fmt.Sprintf("POST %s HTTP/1.1\r\nConnection: close\r\nContent-Length: %d\r\n\r\n%s", path, len(body), body)
+}
+
+// readResponse reads a response with Content-Length header from the reader
+func readResponse(t *testing.T, reader *bufio.Reader) (string, string) {
+	status, err := reader.ReadString('\n')
+	if err != nil {
+		t.Fatal(err)
+	}
+	length := 0
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			t.Fatal(err)
+		}
+		if line == crlf {
+			break
+		}
+		if value, found := strings.CutPrefix(line, "Content-Length: "); found {
+			length, _ = strconv.Atoi(strings.TrimSpace(value))
+		}
+	}
+	body := make([]byte, length)
+	if _, err := io.ReadFull(reader, body); err != nil {
+		t.Fatal(err)
+	}
+	return status, string(body)
+}
+
+func startTestServer(t *testing.T, requests chan serverRequest, getHandler func(getParams) string) (net.Listener, int) {
+	return startTestServerWithHandlers(t, requests, getHandler, nil, nil, nil)
+}
+
+func startTestServerWithHandlers(t *testing.T, requests chan serverRequest, getHandler func(getParams) string, itemHandler func(string, []byte) error, searchHandler func(searchParams) (string, error), configHandler func() string) (net.Listener, int) {
+	listener, port, err := startHttpServer(listenAddress{"localhost", 0, ""}, nil, requests, getHandler, newEventStream(), itemHandler, searchHandler, configHandler)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return listener, port
+}
+
+func TestParseGetParams(t *testing.T) {
+	params, err := parseGetParams("limit=10&offset=5")
+	if err != nil || params.limit != 10 || params.offset != 5 || params.sync || params.timeout != syncTimeout {
+		t.Errorf("invalid params: %v, %v", params, err)
+	}
+	params, _ = parseGetParams("sync&timeout=300")
+	if !params.sync || params.timeout != 300*time.Millisecond {
+		t.Errorf("invalid params: %v", params)
+	}
+	params, _ = parseGetParams("sync=0&timeout=999999999")
+	if params.sync || params.timeout != maxSyncTimeout {
+		t.Errorf("invalid params: %v", params)
+	}
+	params, err = parseGetParams("fields=score,output&selected_only=true")
+	if err != nil || len(params.fields) != 2 || !params.fields["score"] || !params.fields["output"] || !params.selectedOnly {
+		t.Errorf("invalid params: %v, %v", params, err)
+	}
+	if _, err := parseGetParams("fields=score,foo"); err == nil {
+		t.Error("should not accept unknown field")
+	}
+}
+
+func TestSyncPost(t *testing.T) {
+	requests := make(chan serverRequest)
+	applied := false
+	listener, port := startTestServer(t, requests, func(params getParams) string {
+		return fmt.Sprintf(`{"applied":%v,"limit":%d}`, applied, params.limit)
+	})
+	defer listener.Close()
+
+	go func() {
+		// Asynchronous request
+		request := <-requests
+		if request.done != nil || len(request.actions) != 1 || request.actions[0].t != actUp {
+			t.Errorf("unexpected request: %v", request)
+		}
+
+		// Synchronous request
+		request = <-requests
+		time.Sleep(100 * time.Millisecond)
+		applied = true
+		close(request.done)
+
+		// Not applied in time
+		<-requests
+	}()
+
+	response := httpRequest(t, port, postRequest("/", "up"))
+	if response != httpOk+"Connection: close"+crlf+"Content-Length: 0"+crlf+crlf {
+		t.Errorf("unexpected response: %q", response)
+	}
+	response = httpRequest(t, port, postRequest("/?sync&limit=3", "down"))
+	if !strings.HasPrefix(response, httpOk) || !strings.HasSuffix(response, `{"applied":true,"limit":3}`+"\n") {
+		t.Errorf("unexpected response: %q", response)
+	}
+	response = httpRequest(t, port, postRequest("/?sync&timeout=100", "down"))
+	if !strings.HasPrefix(response, httpUnavailable) {
+		t.Errorf("unexpected response: %q", response)
+	}
+}
+
+func TestKeepAlive(t *testing.T) {
+	requests := make(chan serverRequest, 10)
+	count := 0
+	listener, port := startTestServer(t, requests, func(params getParams) string {
+		count++
+		return fmt.Sprintf(`{"count":%d,"limit":%d}`, count, params.limit)
+	})
+	defer listener.Close()
+
+	conn, err := net.Dial("tcp", fmt.Sprintf("localhost:%d", port))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+
+	// Pipelined requests
+	conn.Write([]byte("GET /?limit=1 HTTP/1.1\r\n\r\n" +
+		"POST / HTTP/1.1\r\nContent-Length: 2\r\n\r\nup" +
+		"GET /?limit=2 HTTP/1.1\r\n\r\n"))
+	expected := []string{`{"count":1,"limit":1}` + "\n", "", `{"count":2,"limit":2}` + "\n"}
+	for _, body := range expected {
+		status, response := readResponse(t, reader)
+		if status != httpOk || response != body {
+			t.Errorf("unexpected response: %q, %q", status, response)
+		}
+	}
+	if request := <-requests; request.actions[0].t != actUp {
+		t.Errorf("unexpected request: %v", request)
+	}
+
+	// The connection is still open
+	conn.Write([]byte("GET / HTTP/1.1\r\nConnection: close\r\n\r\n"))
+	if status, response := readResponse(t, reader); status != httpOk || response != `{"count":3,"limit":100}`+"\n" {
+		t.Errorf("unexpected response: %q, %q", status, response)
+	}
+	if _, err := reader.ReadByte(); err != io.EOF {
+		t.Errorf("connection should be closed: %v", err)
+	}
+}
+
+func TestStalledClient(t *testing.T) {
+	listener, port := startTestServer(t, make(chan serverRequest), func(getParams) string {
+		return "{}"
+	})
+	defer listener.Close()
+
+	// A client that never finishes its request
+	stalled, err := net.Dial("tcp", fmt.Sprintf("localhost:%d", port))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stalled.Close()
+	stalled.Write([]byte("GET / HTTP/1.1\r\n"))
+
+	start := time.Now()
+	response := httpRequest(t, port, "GET / HTTP/1.1\r\nConnection: close\r\n\r\n")
+	if !strings.HasPrefix(response, httpOk) {
+		t.Errorf("unexpected response: %q", response)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("blocked by the stalled client: %v", elapsed)
+	}
+}
+
+func TestTooManyConnections(t *testing.T) {
+	listener, port := startTestServer(t, make(chan serverRequest), func(getParams) string {
+		return "{}"
+	})
+	defer listener.Close()
+
+	// Idle keep-alive connections do not hold the workers
+	idle := []*bufio.Reader{}
+	idleConns := []net.Conn{}
+	for range httpMaxWorkers {
+		conn, err := net.Dial("tcp", fmt.Sprintf("localhost:%d", port))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer conn.Close()
+		conn.Write([]byte("GET / HTTP/1.1\r\n\r\n"))
+		reader := bufio.NewReader(conn)
+		readResponse(t, reader)
+		idle = append(idle, reader)
+		idleConns = append(idleConns, conn)
+	}
+	response := httpRequest(t, port, "GET / HTTP/1.1\r\nConnection: close\r\n\r\n")
+	if !strings.HasPrefix(response, httpOk) {
+		t.Errorf("unexpected response: %q", response)
+	}
+
+	// Clients in the middle of their requests do
+	for range httpMaxWorkers {
+		conn, err := net.Dial("tcp", fmt.Sprintf("localhost:%d", port))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer conn.Close()
+		conn.Write([]byte("GET / HTTP/1.1\r\n"))
+	}
+	response = httpRequest(t, port, "GET / HTTP/1.1\r\n\r\n")
+	if !strings.HasPrefix(response, httpUnavailable) {
+		t.Errorf("unexpected response: %q", response)
+	}
+
+	// An idle connection needs a worker for its next request
+	idleConns[0].Write([]byte("GET / HTTP/1.1\r\n\r\n"))
+	if status, _ := readResponse(t, idle[0]); status != httpUnavailable {
+		t.Errorf("unexpected status: %q", status)
+	}
+}
+
+func TestPushItems(t *testing.T) {
+	var pushed []string
+	listener, port := startTestServerWithHandlers(t, make(chan serverRequest), func(getParams) string {
+		return "{}"
+	}, func(op string, body []byte) error {
+		if op == "replace" {
+			return errors.New("--id-nth is required")
+		}
+		pushed = append(pushed, op+":"+string(body))
+		return nil
+	}, nil, nil)
+	defer listener.Close()
+
+	response := httpRequest(t, port, postRequest("/items/append", "foo\nbar"))
+	if !strings.HasPrefix(response, httpOk) || len(pushed) != 1 || pushed[0] != "append:foo\nbar" {
+		t.Errorf("unexpected response: %q, %v", response, pushed)
+	}
+	response = httpRequest(t, port, postRequest("/items/replace", "foo"))
+	if !strings.HasPrefix(response, httpBadRequest) || !strings.Contains(response, "--id-nth is required") {
+		t.Errorf("unexpected response: %q", response)
+	}
+}
+
+func TestParseApiKeys(t *testing.T) {
+	keys, err := parseApiKeys("secret", " status:foo, safe:bar:baz ")
+	if err != nil || len(keys) != 3 {
+		t.Fatalf("unexpected result: %v, %v", keys, err)
+	}
+	expected := []apiKey{{[]byte("secret"), scopeFull}, {[]byte("foo"), scopeStatus}, {[]byte("bar:baz"), scopeSafe}}
+	for i, key := range keys {
+		if string(key.key) != string(expected[i].key) || key.scope != expected[i].scope {
+			t.Errorf("unexpected key: %v", key)
+		}
+	}
+	for _, str := range []string{"foo", "admin:foo", "safe:"} {
+		if _, err := parseApiKeys("", str); err == nil {
+			t.Errorf("should fail: %s", str)
+		}
+	}
+}
+
+func TestApiKeyScopes(t *testing.T) {
+	t.Setenv("FZF_API_KEY", "")
+	t.Setenv("FZF_API_KEYS", "status:viewer,safe:operator,full:admin")
+	requests := make(chan serverRequest, 10)
+	listener, port := startTestServerWithHandlers(t, requests, func(getParams) string {
+		return "{}"
+	}, func(string, []byte) error {
+		return nil
+	}, nil, nil)
+	defer listener.Close()
+
+	request := func(method string, path string, key string, body string) string {
+		if len(body) > 0 {
+			body = fmt.Sprintf("Content-Length: %d\r\n\r\n%s", len(body), body)
+		} else {
+			body = "\r\n"
+		}
+		return httpRequest(t, port, fmt.Sprintf("%s %s HTTP/1.1\r\nConnection: close\r\nx-api-key: %s\r\n%s", method, path, key, body))
+	}
+	for _, key := range []string{"viewer", "operator", "admin"} {
+		if response := request("GET", "/", key, ""); !strings.HasPrefix(response, httpOk) {
+			t.Errorf("%s: unexpected response: %q", key, response)
+		}
+	}
+	if response := request("GET", "/", "nobody", ""); !strings.HasPrefix(response, httpUnauthorized) {
+		t.Errorf("unexpected response: %q", response)
+	}
+
+	for _, path := range []string{"/", "/items/append"} {
+		response := request("POST", path, "viewer", "up")
+		if !strings.HasPrefix(response, httpForbidden) || !strings.Contains(response, "only allows reading the status") {
+			t.Errorf("unexpected response: %q", response)
+		}
+	}
+
+	if response := request("POST", "/", "operator", "up+change-query(foo)"); !strings.HasPrefix(response, httpOk) {
+		t.Errorf("unexpected response: %q", response)
+	}
+	for _, actions := range []string{"up+execute(rm -rf ~)", "become(vim)", "reload(ls)", "trigger(enter)", "play-macro(edit)", "start-macro"} {
+		response := request("POST", "/", "operator", actions)
+		if !strings.HasPrefix(response, httpForbidden) || !strings.Contains(response, "requires full access") {
+			t.Errorf("unexpected response: %q", response)
+		}
+	}
+	if response := request("POST", "/items/append", "operator", "foo"); !strings.HasPrefix(response, httpOk) {
+		t.Errorf("unexpected response: %q", response)
+	}
+	if response := request("POST", "/", "admin", "execute(true)"); !strings.HasPrefix(response, httpOk) {
+		t.Errorf("unexpected response: %q", response)
+	}
+	if len(requests) != 2 {
+		t.Errorf("unexpected number of requests: %d", len(requests))
+	}
+}
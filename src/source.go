@@ -0,0 +1,146 @@
+package fzf
+
+import (
+	"errors"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"sync"
+)
+
+// inputSource is a named input source declared with --source
+type inputSource struct {
+	name     string
+	priority int
+	command  string
+}
+
+var sourceRegex = regexp.MustCompile(`^([a-zA-Z0-9_-]+)(?::([0-9]+))?=(.*)$`)
+
+// parseSource parses NAME[:PRIORITY]=COMMAND
+func parseSource(str string, sources []inputSource) (inputSource, error) {
+	match := sourceRegex.FindStringSubmatch(str)
+	if match == nil {
+		return inputSource{}, errors.New("invalid source definition (expected: NAME[:PRIORITY]=COMMAND): " + str)
+	}
+	source := inputSource{name: match[1], priority: len(sources) + 1, command: match[3]}
+	if len(match[2]) > 0 {
+		priority, err := strconv.Atoi(match[2])
+		if err != nil {
+			return inputSource{}, errors.New("invalid source priority: " + match[2])
+		}
+		source.priority = priority
+	}
+	if len(source.command) == 0 {
+		return inputSource{}, errors.New("source command required: " + source.name)
+	}
+	for _, s := range sources {
+		if s.name == source.name {
+			return inputSource{}, errors.New("duplicate source name: " + source.name)
+		}
+	}
+	if len(sources) >= math.MaxUint8 {
+		return inputSource{}, errors.New("too many sources")
+	}
+	return source, nil
+}
+
+// noSource is the ID of the items that were not read from any of the input
+// sources, e.g. the output of a reload command or the items pushed via the
+// server. They have no {src} and are ranked last by tiebreak=source.
+const noSource = -1
+
+type sourceRun struct {
+	start int32
+	id    int
+}
+
+// sourceTable maps item indexes to the input sources they were read from.
+// Each source streams its items in bursts, so instead of tagging every item,
+// we keep the runs of consecutive indexes that belong to the same source.
+type sourceTable struct {
+	mutex   sync.RWMutex
+	sources []inputSource
+	runs    []sourceRun
+}
+
+func newSourceTable(sources []inputSource) *sourceTable {
+	if len(sources) == 0 {
+		return nil
+	}
+	return &sourceTable{sources: sources}
+}
+
+func appendSourceRun(runs []sourceRun, index int32, id int) []sourceRun {
+	if len(runs) > 0 && runs[len(runs)-1].id == id {
+		return runs
+	}
+	return append(runs, sourceRun{index, id})
+}
+
+// mark records that the item with the given index was read from the source.
+// Indexes should be given in ascending order.
+func (st *sourceTable) mark(index int32, id int) {
+	if st == nil {
+		return
+	}
+	st.mutex.Lock()
+	st.runs = appendSourceRun(st.runs, index, id)
+	st.mutex.Unlock()
+}
+
+// reset replaces the runs of the table
+func (st *sourceTable) reset(runs []sourceRun) {
+	if st == nil {
+		return
+	}
+	st.mutex.Lock()
+	st.runs = runs
+	st.mutex.Unlock()
+}
+
+// lookup returns the ID of the source of the item, or noSource if not known
+func (st *sourceTable) lookup(index int32) int {
+	if st == nil {
+		return noSource
+	}
+	st.mutex.RLock()
+	defer st.mutex.RUnlock()
+	idx := sort.Search(len(st.runs), func(i int) bool {
+		return st.runs[i].start > index
+	})
+	if idx == 0 {
+		return noSource
+	}
+	return st.runs[idx-1].id
+}
+
+// name returns the name of the source of the item
+func (st *sourceTable) name(index int32) string {
+	if id := st.lookup(index); id >= 0 {
+		return st.sources[id].name
+	}
+	return ""
+}
+
+// priority returns the priority of the source of the item
+func (st *sourceTable) priority(index int32) int {
+	if id := st.lookup(index); id >= 0 {
+		return st.sources[id].priority
+	}
+	return math.MaxInt32
+}
+
+// find returns the ID of the source with the given name
+func (st *sourceTable) find(name string) int {
+	if st == nil {
+		return -1
+	}
+	for id, source := range st.sources {
+		if source.name == name {
+			return id
+		}
+	}
+	return -1
+}
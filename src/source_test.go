@@ -0,0 +1,59 @@
+package fzf
+
+import (
+	"testing"
+)
+
+func TestParseSource(t *testing.T) {
+	sources := []inputSource{}
+	for _, str := range []string{"files=fd --type f", "git:0=git ls-files", "buffers=cat a=b"} {
+		source, err := parseSource(str, sources)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sources = append(sources, source)
+	}
+	if sources[0].name != "files" || sources[0].priority != 1 || sources[0].command != "fd --type f" ||
+		sources[1].name != "git" || sources[1].priority != 0 || sources[1].command != "git ls-files" ||
+		sources[2].name != "buffers" || sources[2].priority != 3 || sources[2].command != "cat a=b" {
+		t.Errorf("%v", sources)
+	}
+
+	for _, str := range []string{"", "files", "files=", "a b=ls", "git:-1=ls", "files=ls"} {
+		if _, err := parseSource(str, sources); err == nil {
+			t.Errorf("should fail: %q", str)
+		}
+	}
+}
+
+func TestSourceTable(t *testing.T) {
+	var nilTable *sourceTable
+	if nilTable.lookup(0) != -1 || nilTable.name(0) != "" || nilTable.find("foo") != -1 {
+		t.Error("nil table should not find any source")
+	}
+
+	table := newSourceTable([]inputSource{{"foo", 2, "ls"}, {"bar", 1, "ls"}})
+	for index, id := range []int{0, 0, 1, 1, 1, 0, 1} {
+		table.mark(int32(index), id)
+	}
+	if len(table.runs) != 4 {
+		t.Errorf("%v", table.runs)
+	}
+	for index, name := range []string{"foo", "foo", "bar", "bar", "bar", "foo", "bar", "bar"} {
+		if table.name(int32(index)) != name {
+			t.Errorf("%d: %s", index, table.name(int32(index)))
+		}
+	}
+	if table.priority(0) != 2 || table.priority(2) != 1 || table.find("bar") != 1 {
+		t.Error("invalid priority or ID")
+	}
+	if table.name(-1) != "" || table.priority(-1) <= 2 {
+		t.Error("should not find the source of an unknown item")
+	}
+
+	// Items not read from any source, e.g. the output of a plain reload
+	table.mark(8, noSource)
+	if table.lookup(8) != noSource || table.name(8) != "" || table.priority(8) <= 2 {
+		t.Error("should not find the source of an untagged item")
+	}
+}
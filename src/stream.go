@@ -0,0 +1,160 @@
+package fzf
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	eventBufferSize    = 100
+	streamFlushTimeout = 500 * time.Millisecond
+)
+
+// Events published to the clients of the event stream
+var streamEventNames = []string{"focus", "change", "result", "load", "selection", "accept"}
+
+// The last message sent to a client that is disconnected for being too slow
+var streamLagMessage = []byte("event: error\ndata: {\"error\":\"too slow to receive the events\"}\n\n")
+
+// StatusEvent is the payload of an event in the event stream. It has the
+// fields of Status except for the matches. The selected items are only
+// included in the accept event.
+type StatusEvent struct {
+	Event string `json:"event"`
+	Status
+	Matches       []StatusItem `json:"matches,omitempty"` // Always empty
+	SelectedCount int          `json:"selectedCount"`
+}
+
+type eventSubscriber struct {
+	events  map[string]bool
+	channel chan []byte
+	lagged  bool // Set before the channel is closed
+}
+
+// eventStream broadcasts the events of the finder to the clients connected to
+// the event stream endpoint of the server. The finder never waits for the
+// clients; a client that cannot keep up with the events is disconnected.
+type eventStream struct {
+	mutex       sync.Mutex
+	subscribers map[*eventSubscriber]struct{}
+	count       atomic.Int32
+	closed      bool
+	writers     sync.WaitGroup
+}
+
+func newEventStream() *eventStream {
+	return &eventStream{subscribers: make(map[*eventSubscriber]struct{})}
+}
+
+// parseStreamEvents parses the comma-separated list of event names. An empty
+// list means all events.
+func parseStreamEvents(str string) (map[string]bool, error) {
+	if len(str) == 0 {
+		return nil, nil
+	}
+	events := make(map[string]bool)
+	for _, name := range strings.Split(str, ",") {
+		valid := false
+		for _, known := range streamEventNames {
+			if name == known {
+				valid = true
+				break
+			}
+		}
+		if !valid {
+			return nil, errors.New("invalid event name: " + name)
+		}
+		events[name] = true
+	}
+	return events, nil
+}
+
+// active returns true if there is any client connected to the stream
+func (s *eventStream) active() bool {
+	return s != nil && s.count.Load() > 0
+}
+
+func (s *eventStream) subscribe(events map[string]bool) *eventSubscriber {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.closed {
+		return nil
+	}
+	sub := &eventSubscriber{events: events, channel: make(chan []byte, eventBufferSize)}
+	s.subscribers[sub] = struct{}{}
+	s.count.Store(int32(len(s.subscribers)))
+	s.writers.Add(1)
+	return sub
+}
+
+// unsubscribe should be called by the writer of the subscriber when it stops
+func (s *eventStream) unsubscribe(sub *eventSubscriber) {
+	s.mutex.Lock()
+	s.remove(sub)
+	s.mutex.Unlock()
+	s.writers.Done()
+}
+
+func (s *eventStream) remove(sub *eventSubscriber) {
+	if _, prs := s.subscribers[sub]; prs {
+		delete(s.subscribers, sub)
+		close(sub.channel)
+		s.count.Store(int32(len(s.subscribers)))
+	}
+}
+
+// publish sends the event to the subscribers without blocking
+func (s *eventStream) publish(event StatusEvent) {
+	if !s.active() {
+		return
+	}
+	data, err := json.Marshal(&event)
+	if err != nil {
+		return
+	}
+	message := []byte("event: " + event.Event + "\ndata: " + string(data) + "\n\n")
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for sub := range s.subscribers {
+		if sub.events != nil && !sub.events[event.Event] {
+			continue
+		}
+		select {
+		case sub.channel <- message:
+		default:
+			// The client is too slow to consume the events
+			sub.lagged = true
+			s.remove(sub)
+		}
+	}
+}
+
+// close disconnects all the clients after giving them a chance to receive
+// the pending events
+func (s *eventStream) close() {
+	if s == nil {
+		return
+	}
+	s.mutex.Lock()
+	s.closed = true
+	for sub := range s.subscribers {
+		s.remove(sub)
+	}
+	s.mutex.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		s.writers.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(streamFlushTimeout):
+	}
+}
@@ -0,0 +1,132 @@
+package fzf
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseStreamEvents(t *testing.T) {
+	if events, err := parseStreamEvents(""); err != nil || events != nil {
+		t.Errorf("empty list should allow all events: %v, %v", events, err)
+	}
+	events, err := parseStreamEvents("focus,result")
+	if err != nil || len(events) != 2 || !events["focus"] || !events["result"] {
+		t.Errorf("invalid result: %v, %v", events, err)
+	}
+	if _, err := parseStreamEvents("focus,foo"); err == nil {
+		t.Error("should not accept unknown event")
+	}
+}
+
+func TestEventStreamPublish(t *testing.T) {
+	stream := newEventStream()
+	if stream.active() {
+		t.Error("should not be active without subscribers")
+	}
+	all := stream.subscribe(nil)
+	focus := stream.subscribe(map[string]bool{"focus": true})
+	if !stream.active() {
+		t.Error("should be active")
+	}
+
+	stream.publish(StatusEvent{Event: "change", Status: Status{Query: "foo"}})
+	stream.publish(StatusEvent{Event: "focus", Status: Status{Query: "foo"}})
+	if len(all.channel) != 2 || len(focus.channel) != 1 {
+		t.Errorf("invalid number of events: %d, %d", len(all.channel), len(focus.channel))
+	}
+	message := string(<-focus.channel)
+	if !strings.HasPrefix(message, "event: focus\ndata: {") || !strings.HasSuffix(message, "}\n\n") {
+		t.Errorf("invalid message: %q", message)
+	}
+
+	// The subscriber that does not consume the events is disconnected
+	for i := 0; i < eventBufferSize; i++ {
+		stream.publish(StatusEvent{Event: "result"})
+	}
+	if _, prs := stream.subscribers[all]; prs || !all.lagged {
+		t.Error("slow subscriber should be removed")
+	}
+	if _, prs := stream.subscribers[focus]; !prs {
+		t.Error("filtered subscriber should not be removed")
+	}
+	stream.unsubscribe(all)
+	stream.unsubscribe(focus)
+	if stream.active() {
+		t.Error("should not be active")
+	}
+}
+
+func TestEventStreamLag(t *testing.T) {
+	stream := newEventStream()
+	sub := stream.subscribe(nil)
+	for i := 0; i <= eventBufferSize; i++ {
+		stream.publish(StatusEvent{Event: "result"})
+	}
+
+	// The pending events are followed by the error event
+	conn, client := net.Pipe()
+	go (&httpServer{eventStream: stream}).streamEvents(conn, sub)
+	data, _ := io.ReadAll(client)
+	if bytes.Count(data, []byte("event: result\n")) != eventBufferSize || !bytes.HasSuffix(data, streamLagMessage) {
+		t.Errorf("unexpected messages: %q", data)
+	}
+}
+
+func TestEventStreamServer(t *testing.T) {
+	stream := newEventStream()
+	listener, port, err := startHttpServer(listenAddress{"localhost", 0, ""}, nil, make(chan serverRequest), func(getParams) string { return "" }, stream, nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+
+	conn, err := net.Dial("tcp", fmt.Sprintf("localhost:%d", port))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	conn.Write([]byte("GET /events?events=accept HTTP/1.1" + crlf + crlf))
+	reader := bufio.NewReader(conn)
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			t.Fatal(err)
+		}
+		if line == crlf {
+			break
+		}
+	}
+
+	for !stream.active() {
+		time.Sleep(10 * time.Millisecond)
+	}
+	stream.publish(StatusEvent{Event: "focus"})
+	stream.publish(StatusEvent{Event: "accept", Status: Status{Query: "foo", Selected: []StatusItem{{Index: 1, Text: "bar"}}}})
+	stream.close()
+
+	line, _ := reader.ReadString('\n')
+	if line != "event: accept\n" {
+		t.Errorf("unexpected event: %q", line)
+	}
+	line, _ = reader.ReadString('\n')
+	var event StatusEvent
+	if err := json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &event); err != nil {
+		t.Fatal(err)
+	}
+	if event.Query != "foo" || len(event.Selected) != 1 || event.Selected[0].Text != "bar" {
+		t.Errorf("unexpected payload: %v", event)
+	}
+	if _, err := reader.ReadString('\n'); err != nil {
+		t.Error(err)
+	}
+	if _, err := reader.ReadString('\n'); err == nil {
+		t.Error("connection should be closed")
+	}
+}
@@ -3,6 +3,7 @@ package fzf
 import (
 	"bufio"
 	"context"
+	"crypto/tls"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -73,7 +74,7 @@ const blockDuration = 1 * time.Second
 const maxCurrentItemEnvSize = 64 * 1024
 
 func init() {
-	placeholder = regexp.MustCompile(`\\?(?:{[+*sfr]*[0-9,-.]*}|{q(?::s?[0-9,-.]+)?}|{fzf:(?:query|action|prompt)}|{[+*]?f?nf?})`)
+	placeholder = regexp.MustCompile(`\\?(?:{[+*sfr]*[0-9,-.]*}|{q(?::s?[0-9,-.]+)?}|{fzf:(?:query|action|prompt)}|{[+*]?f?nf?}|{[+*]?src})`)
 	whiteSuffix = regexp.MustCompile(`\s*$`)
 	offsetComponentRegex = regexp.MustCompile(`([+-][0-9]+)|(-?/[1-9][0-9]*)`)
 	offsetTrimCharsRegex = regexp.MustCompile(`[^0-9/+-]`)
@@ -137,6 +138,7 @@ func (s *resumableState) Set(flag bool) {
 type commandSpec struct {
 	command   string
 	tempFiles []string
+	source    string // Name of the input source to reload
 }
 
 type quitSignal struct {
@@ -221,9 +223,13 @@ const (
 )
 
 type StatusItem struct {
-	Index     int    `json:"index"`
-	Text      string `json:"text"`
-	Positions []int  `json:"positions,omitempty"`
+	Index     int     `json:"index"`
+	Text      string  `json:"text"`
+	Positions []int   `json:"positions,omitempty"`
+	Score     *int    `json:"score,omitempty"`
+	Display   *string `json:"display,omitempty"`
+	Output    *string `json:"output,omitempty"`
+	Selected  *bool   `json:"selected,omitempty"`
 }
 
 type Status struct {
@@ -237,6 +243,12 @@ type Status struct {
 	Current    *StatusItem  `json:"current"`
 	Matches    []StatusItem `json:"matches"`
 	Selected   []StatusItem `json:"selected"`
+
+	// Optional fields
+	Prompt      *string  `json:"prompt,omitempty"`
+	Nth         *string  `json:"nth,omitempty"`
+	Header      []string `json:"header,omitempty"`
+	HeaderLines []string `json:"headerLines,omitempty"`
 }
 
 type versionedCallback struct {
@@ -314,6 +326,10 @@ type Terminal struct {
 	yanked               []rune
 	input                []rune
 	inputOverride        *[]rune
+	undoHistory          undoHistory
+	macros               *Macros
+	macroRecording       *macroRecording
+	playingMacros        map[string]bool
 	pasting              *[]rune
 	multi                int
 	multiLine            bool
@@ -326,15 +342,24 @@ type Terminal struct {
 	trackSync            bool
 	trackKeyCache        map[int32]bool
 	pendingSelections    map[string]selectedItem
+	keptSelections       map[int32]selectedItem
 	targetIndex          int32
 	delimiter            Delimiter
+	sources              *sourceTable
 	expect               map[tui.Event]string
 	keymap               map[tui.Event][]*action
 	keymapOrg            map[tui.Event][]*action
+	sequences            map[string][]*action
+	sequencesOrg         map[string][]*action
+	sequenceTimeout      time.Duration
+	pendingKeys          []tui.Event
+	vi                   *viState
 	pressed              string
 	printQueue           []string
 	printQuery           bool
 	history              *History
+	historySearch        *historySearch
+	historyPattern       func([]rune) *Pattern
 	cycle                bool
 	highlightLine        bool
 	headerVisible        bool
@@ -364,6 +389,11 @@ type Terminal struct {
 	listenPort           *int
 	listener             net.Listener
 	listenUnsafe         bool
+	listenTLS            *tlsOpts
+	tlsCertFile          string
+	eventStream          *eventStream
+	streamFocus          int32
+	streamLoad           bool
 	borderShape          tui.BorderShape
 	listBorderShape      tui.BorderShape
 	inputBorderShape     tui.BorderShape
@@ -404,6 +434,8 @@ type Terminal struct {
 	reading              bool
 	running              *util.AtomicBool
 	failed               *string
+	inputError           error
+	macroError           error
 	jumping              jumpMode
 	jumpLabels           string
 	printer              func(string)
@@ -433,7 +465,14 @@ type Terminal struct {
 	startChan            chan fitpad
 	killChan             chan bool
 	killedChan           chan bool
-	serverInputChan      chan []*action
+	serverInputChan      chan serverRequest
+	pushQueue            requestQueue[pushRequest]
+	searchQueue          requestQueue[headlessSearch]
+	searchMutex          sync.Mutex
+	searches             map[string]*util.AtomicBool
+	readZero             bool
+	searchSeq            int64
+	syncWaiters          []syncWaiter
 	callbackChan         chan versionedCallback
 	bgQueue              map[action][]func(bool)
 	bgSemaphore          chan struct{}
@@ -462,6 +501,10 @@ type Terminal struct {
 	numLinesCache        map[int32]numLinesCacheValue
 	raw                  bool
 	lastActivity         time.Time
+	recorder             *sessionRecorder
+	replay               *sessionReplay
+	lastChange           time.Time
+	debounceTimers       map[tui.Event]*time.Timer
 }
 
 type numLinesCacheValue struct {
@@ -701,6 +744,12 @@ const (
 	actPut
 	actNextHistory
 	actNextSelected
+	actSearchHistory
+	actUndo
+	actRedo
+	actStartMacro
+	actStopMacro
+	actPlayMacro
 	actExecute
 	actExecuteSilent
 	actExecuteMulti // Deprecated
@@ -710,6 +759,7 @@ const (
 	actLast
 	actReload
 	actReloadSync
+	actReloadSource
 	actDisableSearch
 	actEnableSearch
 	actSelect
@@ -774,6 +824,7 @@ func processExecution(action actionType) bool {
 		actExecuteMulti,
 		actReload,
 		actReloadSync,
+		actReloadSource,
 		actBecome:
 		return true
 	}
@@ -788,6 +839,7 @@ type placeholderFlags struct {
 	forceUpdate   bool
 	file          bool
 	raw           bool
+	source        bool
 }
 
 type withNthSpec struct {
@@ -805,6 +857,15 @@ type searchRequest struct {
 	changed     bool
 	denylist    []int32
 	revision    revision
+	seq         int64
+}
+
+// syncWaiter is a client of the server waiting for the result of the search
+// request with the sequence number
+type syncWaiter struct {
+	seq   int64
+	final bool
+	done  chan struct{}
 }
 
 type previewRequest struct {
@@ -920,7 +981,7 @@ func mayTriggerPreview(opts *Options) bool {
 	if opts.ListenAddr != nil {
 		return true
 	}
-	for _, actions := range opts.Keymap {
+	for _, actions := range slices.Concat(slices.Collect(maps.Values(opts.Keymap)), slices.Collect(maps.Values(opts.Sequences))) {
 		for _, action := range actions {
 			switch action.t {
 			case actPreview, actChangePreview, actTransform, actBgTransform:
@@ -953,7 +1014,7 @@ func evaluateHeight(opts *Options, termHeight int) int {
 }
 
 // NewTerminal returns new Terminal object
-func NewTerminal(opts *Options, eventBox *util.EventBox, executor *util.Executor) (*Terminal, error) {
+func NewTerminal(opts *Options, eventBox *util.EventBox, executor *util.Executor, sources *sourceTable) (*Terminal, error) {
 	input := trimQuery(opts.Query)
 	var delay time.Duration
 	if opts.Sync {
@@ -973,39 +1034,62 @@ func NewTerminal(opts *Options, eventBox *util.EventBox, executor *util.Executor
 	}
 	var renderer tui.Renderer
 	fullscreen := !opts.Height.auto && (opts.Height.size == 0 || opts.Height.percent && opts.Height.size == 100)
-	var err error
-	// Reuse ttyin if available to avoid having multiple file descriptors open
-	// when you run fzf multiple times in your Go program. Closing it is known to
-	// cause problems with 'become' action and invalid terminal state after exit.
-	if ttyin == nil {
-		if ttyin, err = tui.TtyIn(opts.TtyDefault); err != nil {
-			return nil, err
+	maxHeightFunc := func(termHeight int) int {
+		if fullscreen {
+			return termHeight
+		}
+		// Minimum height required to render fzf excluding margin and padding
+		effectiveMinHeight := minHeight
+		if previewBox != nil && opts.Preview.aboveOrBelow() {
+			effectiveMinHeight += 1 + borderLines(opts.Preview.Border(opts.Layout))
+		}
+		if opts.noSeparatorLine() {
+			effectiveMinHeight--
 		}
+		effectiveMinHeight += borderLines(opts.BorderShape)
+		return min(termHeight, max(evaluateHeight(opts, termHeight), effectiveMinHeight))
 	}
-	if fullscreen {
-		if tui.HasFullscreenRenderer() {
-			renderer = tui.NewFullscreenRenderer(opts.Theme, opts.Black, opts.Mouse, opts.Tabstop)
-		} else {
-			renderer, err = tui.NewLightRenderer(opts.TtyDefault, ttyin, opts.Theme, opts.Black, opts.Mouse, opts.Tabstop, opts.ClearOnExit,
-				true, func(h int) int { return h })
+	var err error
+	var recorder *sessionRecorder
+	var replay *sessionReplay
+	if len(opts.Replay) > 0 {
+		// Replay the recorded session without the terminal
+		if replay, err = loadSessionReplay(opts.Replay, opts.Tabstop, maxHeightFunc); err != nil {
+			return nil, err
 		}
+		renderer = replay
+	} else if opts.renderer != nil {
+		renderer = opts.renderer
+		renderer.Resize(maxHeightFunc)
 	} else {
-		maxHeightFunc := func(termHeight int) int {
-			// Minimum height required to render fzf excluding margin and padding
-			effectiveMinHeight := minHeight
-			if previewBox != nil && opts.Preview.aboveOrBelow() {
-				effectiveMinHeight += 1 + borderLines(opts.Preview.Border(opts.Layout))
+		// Reuse ttyin if available to avoid having multiple file descriptors open
+		// when you run fzf multiple times in your Go program. Closing it is known to
+		// cause problems with 'become' action and invalid terminal state after exit.
+		if ttyin == nil {
+			if ttyin, err = tui.TtyIn(opts.TtyDefault); err != nil {
+				return nil, err
 			}
-			if opts.noSeparatorLine() {
-				effectiveMinHeight--
+		}
+		if fullscreen {
+			if tui.HasFullscreenRenderer() {
+				renderer = tui.NewFullscreenRenderer(opts.Theme, opts.Black, opts.Mouse, opts.Tabstop)
+			} else {
+				renderer, err = tui.NewLightRenderer(opts.TtyDefault, ttyin, opts.Theme, opts.Black, opts.Mouse, opts.Tabstop, opts.ClearOnExit,
+					true, maxHeightFunc)
 			}
-			effectiveMinHeight += borderLines(opts.BorderShape)
-			return min(termHeight, max(evaluateHeight(opts, termHeight), effectiveMinHeight))
+		} else {
+			renderer, err = tui.NewLightRenderer(opts.TtyDefault, ttyin, opts.Theme, opts.Black, opts.Mouse, opts.Tabstop, opts.ClearOnExit, false, maxHeightFunc)
+		}
+		if err != nil {
+			return nil, err
 		}
-		renderer, err = tui.NewLightRenderer(opts.TtyDefault, ttyin, opts.Theme, opts.Black, opts.Mouse, opts.Tabstop, opts.ClearOnExit, false, maxHeightFunc)
 	}
-	if err != nil {
-		return nil, err
+	if len(opts.Record) > 0 {
+		if recorder, err = newSessionRecorder(opts.Record); err != nil {
+			return nil, err
+		}
+		recorder.Renderer = renderer
+		renderer = recorder
 	}
 	if opts.Inputless {
 		renderer.HideCursor()
@@ -1020,6 +1104,15 @@ func NewTerminal(opts *Options, eventBox *util.EventBox, executor *util.Executor
 		wordNext = fmt.Sprintf("[^%s]%s|(.$)", sep, sep)
 	}
 	keymapCopy := maps.Clone(opts.Keymap)
+	var vi *viState
+	if opts.ViMode {
+		vi = &viState{}
+	}
+	macros := opts.Macros
+	if macros == nil {
+		// Macros are not persisted
+		macros, _ = NewMacros("")
+	}
 
 	em := EmptyMerger(revision{})
 	t := Terminal{
@@ -1054,26 +1147,36 @@ func NewTerminal(opts *Options, eventBox *util.EventBox, executor *util.Executor
 		yanked:             []rune{},
 		input:              input,
 		multi:              opts.Multi,
-		multiLine:          opts.ReadZero && opts.MultiLine,
+		multiLine:          (opts.ReadZero || opts.Delimiter.csv) && opts.MultiLine,
 		wrap:               opts.Wrap,
 		wrapWord:           opts.WrapWord,
 		sort:               opts.Sort > 0,
 		toggleSort:         opts.ToggleSort,
 		track:              opts.Track,
 		idNth:              opts.IdNth,
+		readZero:           opts.ReadZero,
 		targetIndex:        minItem.Index(),
 		delimiter:          opts.Delimiter,
+		sources:            sources,
 		expect:             opts.Expect,
 		keymap:             opts.Keymap,
 		keymapOrg:          keymapCopy,
+		sequences:          opts.Sequences,
+		sequencesOrg:       maps.Clone(opts.Sequences),
+		vi:                 vi,
 		pressed:            "",
 		printQuery:         opts.PrintQuery,
 		history:            opts.History,
+		macros:             macros,
+		playingMacros:      make(map[string]bool),
+		historyPattern:     newHistoryPatternBuilder(opts),
 		margin:             opts.Margin,
 		padding:            opts.Padding,
 		unicode:            opts.Unicode,
 		listenAddr:         opts.ListenAddr,
+		listenTLS:          opts.ListenTLS,
 		listenUnsafe:       opts.Unsafe,
+		streamFocus:        minItem.Index(),
 		borderShape:        opts.BorderShape,
 		listBorderShape:    opts.ListBorderShape,
 		inputBorderShape:   opts.InputBorderShape,
@@ -1152,7 +1255,7 @@ func NewTerminal(opts *Options, eventBox *util.EventBox, executor *util.Executor
 		startChan:          make(chan fitpad, 1),
 		killChan:           make(chan bool),
 		killedChan:         make(chan bool),
-		serverInputChan:    make(chan []*action, 100),
+		serverInputChan:    make(chan serverRequest, 100),
 		callbackChan:       make(chan versionedCallback, maxBgProcesses),
 		bgQueue:            make(map[action][]func(bool)),
 		bgSemaphore:        make(chan struct{}, maxBgProcesses),
@@ -1163,6 +1266,8 @@ func NewTerminal(opts *Options, eventBox *util.EventBox, executor *util.Executor
 		tui:                renderer,
 		ttyDefault:         opts.TtyDefault,
 		ttyin:              ttyin,
+		recorder:           recorder,
+		replay:             replay,
 		initFunc:           func() error { return renderer.Init() },
 		executing:          util.NewAtomicBool(false),
 		lastAction:         actStart,
@@ -1170,7 +1275,7 @@ func NewTerminal(opts *Options, eventBox *util.EventBox, executor *util.Executor
 		lastActivity:       time.Now(),
 		numLinesCache:      make(map[int32]numLinesCacheValue)}
 	if opts.AcceptNth != nil {
-		t.acceptNth = opts.AcceptNth(t.delimiter)
+		t.acceptNth = opts.AcceptNth(t.delimiter, t.sources.name)
 	}
 
 	baseTheme := opts.BaseTheme
@@ -1218,6 +1323,11 @@ func NewTerminal(opts *Options, eventBox *util.EventBox, executor *util.Executor
 	}
 	t.markerEmpty = strings.Repeat(" ", t.markerLen)
 
+	t.sequenceTimeout = opts.SequenceTimeout
+	if t.sequenceTimeout == 0 {
+		t.sequenceTimeout = defaultSequenceTimeout
+	}
+
 	// Labels
 	t.listLabel, t.listLabelLen = t.ansiLabelPrinter(opts.ListLabel.label, &tui.ColListLabel, false)
 	t.borderLabel, t.borderLabelLen = t.ansiLabelPrinter(opts.BorderLabel.label, &tui.ColBorderLabel, false)
@@ -1358,8 +1468,17 @@ func NewTerminal(opts *Options, eventBox *util.EventBox, executor *util.Executor
 	_, t.hasLoadActions = t.keymap[tui.Load.AsEvent()]
 
 	if t.listenAddr != nil {
-		listener, port, err := startHttpServer(*t.listenAddr, t.serverInputChan, t.dumpStatus)
+		var tlsConfig *tls.Config
+		if t.listenTLS != nil {
+			var err error
+			if tlsConfig, t.tlsCertFile, err = t.listenTLS.serverConfig(t.listenAddr.host); err != nil {
+				return nil, err
+			}
+		}
+		t.eventStream = newEventStream()
+		listener, port, err := startHttpServer(*t.listenAddr, tlsConfig, t.serverInputChan, t.dumpStatus, t.eventStream, t.pushItems, t.search, t.dumpConfig)
 		if err != nil {
+			t.removeCertFile()
 			return nil, err
 		}
 		t.listener = listener
@@ -1375,6 +1494,13 @@ func NewTerminal(opts *Options, eventBox *util.EventBox, executor *util.Executor
 	return &t, nil
 }
 
+// removeCertFile removes the temporary file of the self-signed certificate
+func (t *Terminal) removeCertFile() {
+	if t.listenTLS != nil && t.listenTLS.selfSigned() && len(t.tlsCertFile) > 0 {
+		os.Remove(t.tlsCertFile)
+	}
+}
+
 func (t *Terminal) deferActivation() bool {
 	return t.initDelay == 0 && (t.hasStartActions || t.hasLoadActions || t.hasResultActions || t.hasFocusActions)
 }
@@ -1395,6 +1521,9 @@ func (t *Terminal) environImpl(forPreview bool) []string {
 	if t.listenPort != nil {
 		env = append(env, fmt.Sprintf("FZF_PORT=%d", *t.listenPort))
 	}
+	if len(t.tlsCertFile) > 0 {
+		env = append(env, "FZF_TLS_CERT="+t.tlsCertFile)
+	}
 	env = append(env, "FZF_QUERY="+string(t.input))
 	env = append(env, "FZF_ACTION="+t.lastAction.Name())
 	env = append(env, "FZF_KEY="+t.lastKey)
@@ -1432,6 +1561,8 @@ func (t *Terminal) environImpl(forPreview bool) []string {
 		inputState = "hidden"
 	} else if t.paused {
 		inputState = "disabled"
+	} else if t.vi != nil {
+		inputState = t.vi.mode.String()
 	}
 	if t.wrap {
 		if t.wrapWord {
@@ -1644,6 +1775,9 @@ func (t *Terminal) withWindow(w tui.Window, f func()) {
 func (t *Terminal) parsePrompt(prompt string) (func(), int) {
 	var state *ansiState
 	prompt = firstLine(prompt)
+	if t.vi != nil {
+		prompt = strings.ReplaceAll(prompt, viModePlaceholder, t.vi.mode.String())
+	}
 	trimmed, colors, _ := extractColor(prompt, state, nil)
 	item := &Item{text: util.ToChars([]byte(trimmed)), colors: colors}
 
@@ -1808,14 +1942,18 @@ func (t *Terminal) ResumeRendering() {
 }
 
 // UpdateCount updates the count information
-func (t *Terminal) UpdateCount(cnt int, final bool, failedCommand *string) {
+func (t *Terminal) UpdateCount(cnt int, final bool, failedCommand *string, inputError error) {
 	t.mutex.Lock()
 	t.count = cnt
 	if t.hasLoadActions && t.reading && final {
 		t.triggerLoad = true
 	}
+	if t.reading && final {
+		t.streamLoad = true
+	}
 	t.reading = !final
 	t.failed = failedCommand
+	t.inputError = inputError
 	suppressed := t.suppress
 	t.mutex.Unlock()
 	t.reqBox.Set(reqInfo, nil)
@@ -1917,6 +2055,11 @@ func (t *Terminal) UpdateList(result MatchResult) {
 				}
 			}
 			t.selected = make(map[int32]selectedItem)
+			if t.keptSelections != nil {
+				// reload-source: the items from the other sources keep their indexes
+				t.selected = t.keptSelections
+				t.keptSelections = nil
+			}
 			t.clearNumLinesCache()
 		} else {
 			// Trimmed by --tail: filter selection by index
@@ -1959,6 +2102,11 @@ func (t *Terminal) UpdateList(result MatchResult) {
 		t.pendingReqList = true
 		t.eventChan <- tui.Load.AsEvent()
 	}
+	t.publishEvent("result")
+	if t.streamLoad {
+		t.streamLoad = false
+		t.publishEvent("load")
+	}
 	// Search for the tracked item by nth key
 	// - reload (async): search eagerly, unblock as soon as match is found
 	// - reload-sync: wait until stream is complete before searching
@@ -2046,6 +2194,17 @@ func (t *Terminal) UpdateList(result MatchResult) {
 			}
 		}
 	}
+	if len(t.syncWaiters) > 0 {
+		waiters := t.syncWaiters[:0]
+		for _, waiter := range t.syncWaiters {
+			if result.seq >= waiter.seq && (!waiter.final || merger.final) {
+				close(waiter.done)
+			} else {
+				waiters = append(waiters, waiter)
+			}
+		}
+		t.syncWaiters = waiters
+	}
 	updateList := !t.trackBlocked && !t.pendingReqList
 	updatePrompt := trackWasBlocked && !t.trackBlocked
 	t.mutex.Unlock()
@@ -3254,6 +3413,14 @@ func (t *Terminal) printPrompt() {
 	}
 	t.prompt()
 
+	if t.historySearch != nil {
+		maxWidth := max(1, w.Width()-t.promptLen-1)
+		query, _ := t.trimLeft(t.historySearch.query, maxWidth, 0)
+		t.queryLen = [2]int{t.displayWidth(query), 0}
+		w.CPrint(tui.ColInput, string(query))
+		return
+	}
+
 	before, after := t.updatePromptOffset()
 	if len(before) == 0 && len(after) == 0 && len(t.ghost) > 0 {
 		maxWidth := max(1, w.Width()-t.promptLen-1)
@@ -3383,6 +3550,18 @@ func (t *Terminal) printInfoImpl() {
 	if t.failed != nil && t.count == 0 {
 		output = fmt.Sprintf("[Command failed: %s]", *t.failed)
 	}
+	if t.inputError != nil {
+		output += fmt.Sprintf(" [%s]", t.inputError)
+	}
+	if t.macroError != nil {
+		output += fmt.Sprintf(" [failed to save macro: %s]", t.macroError)
+	}
+	if len(t.pendingKeys) > 0 {
+		output += fmt.Sprintf(" [%s]", keySequenceName(t.pendingKeys))
+	}
+	if t.historySearch != nil {
+		output = fmt.Sprintf("%d/%d (history)", len(t.historySearch.matches), len(t.historySearch.entries))
+	}
 	var outputPrinter labelPrinter
 	outputLen := len(output)
 	if t.infoCommand != "" {
@@ -3755,6 +3934,11 @@ func (t *Terminal) printList() {
 	startLine := t.promptLines() + t.visibleHeaderLinesInList()
 	maxy += startLine
 
+	if t.historySearch != nil {
+		t.printHistorySearch(startLine, maxy)
+		return
+	}
+
 	barRange := [2]int{startLine + barStart, startLine + barStart + barLength}
 	for line, itemCount := startLine, 0; line <= maxy; line, itemCount = line+1, itemCount+1 {
 		if itemCount < count {
@@ -5170,6 +5354,12 @@ func parsePlaceholder(match string) (bool, string, placeholderFlags) {
 		return false, match, flags
 	}
 
+	if strings.HasSuffix(match, "src}") {
+		// {src}, {+src}, {*src}
+		flags.source = true
+		match = match[:len(match)-4] + "}"
+	}
+
 	trimmed := ""
 	for _, char := range match[1:] {
 		switch char {
@@ -5223,6 +5413,7 @@ type replacePlaceholderParams struct {
 	lastAction actionType
 	prompt     string
 	executor   *util.Executor
+	sources    *sourceTable
 }
 
 func (t *Terminal) replacePlaceholderInInitialCommand(template string) (string, []string) {
@@ -5241,6 +5432,7 @@ func (t *Terminal) replacePlaceholder(template string, forcePlus bool, input str
 		lastAction: t.lastAction,
 		prompt:     t.promptString,
 		executor:   t.executor,
+		sources:    t.sources,
 	})
 }
 
@@ -5319,6 +5511,8 @@ func replacePlaceholder(params replacePlaceholderParams) (string, []string) {
 		case match == "{}":
 			replace = func(item *Item) string {
 				switch {
+				case flags.source:
+					return params.executor.QuoteEntry(params.sources.name(item.Index()))
 				case flags.number:
 					n := item.text.Index
 					if n == minItem.Index() {
@@ -5356,7 +5550,7 @@ func replacePlaceholder(params replacePlaceholderParams) (string, []string) {
 			}
 
 			replace = func(item *Item) string {
-				tokens := Tokenize(item.AsString(params.stripAnsi), params.delimiter)
+				tokens := UnquoteTokens(Tokenize(item.AsString(params.stripAnsi), params.delimiter), params.delimiter)
 				trans := Transform(tokens, ranges)
 				str := JoinTokens(trans)
 
@@ -5777,8 +5971,72 @@ func (t *Terminal) currentIndex() int32 {
 }
 
 func (t *Terminal) trackKeyFor(item *Item, nth []Range) string {
-	tokens := Tokenize(item.AsString(t.ansi), t.delimiter)
-	return StripLastDelimiter(JoinTokens(Transform(tokens, nth)), t.delimiter)
+	return itemKey(item.AsString(t.ansi), nth, t.delimiter)
+}
+
+// pushItems handles the request from the server to append, replace, or
+// remove items. The items are processed asynchronously by the main event loop.
+func (t *Terminal) pushItems(operation string, body []byte) error {
+	op, err := parsePushOp(operation)
+	if err != nil {
+		return err
+	}
+	if op != pushAppend && len(t.idNth) == 0 {
+		return fmt.Errorf("--id-nth is required to %s items", operation)
+	}
+	delim := byte('\n')
+	if t.readZero {
+		delim = 0
+	}
+	t.pushQueue.add(pushRequest{op, splitPushedLines(body, delim)})
+	t.eventBox.Set(EvtPushItems, (*string)(nil))
+	return nil
+}
+
+// search handles the request from the server to search the list without
+// changing the state of the terminal. A previous search with the same ID is
+// cancelled.
+func (t *Terminal) search(params searchParams) (string, error) {
+	cancelled := util.NewAtomicBool(false)
+	if len(params.id) > 0 {
+		t.searchMutex.Lock()
+		if t.searches == nil {
+			t.searches = make(map[string]*util.AtomicBool)
+		}
+		if prev, found := t.searches[params.id]; found {
+			prev.Set(true)
+		}
+		t.searches[params.id] = cancelled
+		t.searchMutex.Unlock()
+		defer func() {
+			t.searchMutex.Lock()
+			if t.searches[params.id] == cancelled {
+				delete(t.searches, params.id)
+			}
+			t.searchMutex.Unlock()
+		}()
+	}
+
+	result := make(chan *Merger, 1)
+	t.searchQueue.add(headlessSearch{[]rune(params.query), cancelled, result})
+	t.eventBox.Set(EvtHeadlessSearch, nil)
+
+	var merger *Merger
+	select {
+	case merger = <-result:
+	case <-time.After(params.timeout):
+		cancelled.Set(true)
+		return "", errSearchTimeout
+	}
+	if merger == nil {
+		return "", errSearchCancelled
+	}
+	bytes, _ := json.Marshal(SearchResult{
+		Query:      params.query,
+		MatchCount: merger.Length(),
+		Matches:    searchItems(merger, params.offset, params.limit, t.ansi),
+	})
+	return string(bytes), nil
 }
 
 func (t *Terminal) unblockTrack() {
@@ -5911,10 +6169,25 @@ func (t *Terminal) addClickFooterWord(env []string) []string {
 
 // startTimers spawns a goroutine per every() bind event. Forwarding ticks
 // onto the unbuffered timerChan lets the ticker drop overlapping ticks
-// while the main loop is busy.
+// while the main loop is busy. It also sets up the timers for idle() and
+// change() events, which are restarted on user input and query changes.
 func (t *Terminal) startTimers(ctx context.Context) {
+	t.debounceTimers = make(map[tui.Event]*time.Timer)
 	for evt := range t.keymap {
 		switch evt.Type {
+		case tui.Idle, tui.DebouncedChange:
+			evt := evt
+			timer := time.AfterFunc(time.Duration(evt.Char)*time.Millisecond, func() {
+				select {
+				case <-ctx.Done():
+				case t.timerChan <- evt:
+				}
+			})
+			if evt.Type == tui.DebouncedChange {
+				// Armed on query change
+				timer.Stop()
+			}
+			t.debounceTimers[evt] = timer
 		case tui.Every:
 			d := time.Duration(evt.Char) * time.Millisecond
 			evt := evt
@@ -5938,6 +6211,35 @@ func (t *Terminal) startTimers(ctx context.Context) {
 	}
 }
 
+// resetTimers restarts the timers for the given type of debounced events
+func (t *Terminal) resetTimers(eventType tui.EventType) {
+	for evt, timer := range t.debounceTimers {
+		if evt.Type == eventType {
+			timer.Reset(time.Duration(evt.Char) * time.Millisecond)
+		}
+	}
+}
+
+// restartChangeTimers restarts the timers for change() events so that they
+// are triggered when the query stops changing
+func (t *Terminal) restartChangeTimers() {
+	t.lastChange = time.Now()
+	t.resetTimers(tui.DebouncedChange)
+}
+
+// timerExpired returns false if the timer event is outdated because of the
+// input or the query change after the timer fired
+func (t *Terminal) timerExpired(evt tui.Event) bool {
+	d := time.Duration(evt.Char) * time.Millisecond
+	switch evt.Type {
+	case tui.Idle:
+		return time.Since(t.lastActivity) >= d
+	case tui.DebouncedChange:
+		return time.Since(t.lastChange) >= d
+	}
+	return true
+}
+
 // Loop is called to start Terminal I/O
 func (t *Terminal) Loop() error {
 	// prof := profile.Start(profile.ProfilePath("/tmp/"))
@@ -6220,7 +6522,9 @@ func (t *Terminal) Loop() error {
 			}
 			if t.listener != nil {
 				t.listener.Close()
+				t.removeCertFile()
 			}
+			t.eventStream.close()
 			t.tui.Close()
 			code = getCode()
 			if code <= ExitNoMatch && t.history != nil {
@@ -6285,6 +6589,10 @@ func (t *Terminal) Loop() error {
 							info = true
 						}
 						focusChanged := focusedIndex != currentIndex
+						if currentIndex != t.streamFocus && t.eventStream.active() {
+							t.streamFocus = currentIndex
+							t.publishEvent("focus")
+						}
 						if (t.hasFocusActions || t.infoCommand != "") && focusChanged && currentIndex != t.lastFocus {
 							t.lastFocus = currentIndex
 							t.eventChan <- tui.Focus.AsEvent()
@@ -6346,6 +6654,9 @@ func (t *Terminal) Loop() error {
 						}
 						if req == reqResize {
 							t.termSize = t.tui.Size()
+							if t.recorder != nil {
+								t.recorder.recordResize(t.termSize)
+							}
 						}
 						wasHidden := t.pwindow == nil
 						if req == reqRedraw {
@@ -6360,6 +6671,7 @@ func (t *Terminal) Loop() error {
 							t.eventChan <- tui.Resize.AsEvent()
 						}
 					case reqClose:
+						t.publishEvent("accept")
 						exit(func() int {
 							if t.output() {
 								return ExitOk
@@ -6453,6 +6765,7 @@ func (t *Terminal) Loop() error {
 	wasDown := false
 	pmx, pmy := -1, -1
 	needBarrier := true
+	var sequenceTimer <-chan time.Time
 
 	// If an action is bound to 'start', we're going to process it before reading
 	// user input.
@@ -6482,8 +6795,22 @@ func (t *Terminal) Loop() error {
 		}
 	}
 
+	// The channel to close when the actions of a sync request from the server
+	// take effect. If an iteration ends early, it is closed on the next
+	// iteration or when the loop ends.
+	var syncDone chan struct{}
+	defer func() {
+		if syncDone != nil {
+			close(syncDone)
+		}
+	}()
+
 	// The main event loop
 	for loopIndex := int64(0); looping; loopIndex++ {
+		if syncDone != nil {
+			close(syncDone)
+			syncDone = nil
+		}
 		events = []util.EventType{}
 		changed = false
 		newNth = nil
@@ -6506,12 +6833,19 @@ func (t *Terminal) Loop() error {
 		}
 
 		var event tui.Event
+		sequenceExpired := false
 		actions := []*action{}
 		callbacks := []versionedCallback{}
 		select {
 		case event = <-t.keyChan:
 			needBarrier = true
 		case event = <-t.timerChan:
+			if !t.timerExpired(event) {
+				continue
+			}
+		case <-sequenceTimer:
+			event = tui.Invalid.AsEvent()
+			sequenceExpired = true
 		case event = <-t.eventChan:
 			// Drain channel to process all queued events at once without rendering
 			// the intermediate states
@@ -6529,13 +6863,14 @@ func (t *Terminal) Loop() error {
 					}
 				}
 			}
-		case serverActions := <-t.serverInputChan:
+		case request := <-t.serverInputChan:
 			event = tui.Invalid.AsEvent()
+			syncDone = request.done
 			if t.listenAddr == nil || t.listenAddr.IsLocal() || t.listenUnsafe {
-				actions = serverActions
+				actions = request.actions
 			} else {
-				for _, action := range serverActions {
-					if !processExecution(action.t) {
+				for _, action := range request.actions {
+					if !processExecution(action.t) && !macroAction(action.t) {
 						actions = append(actions, action)
 					}
 				}
@@ -6576,9 +6911,25 @@ func (t *Terminal) Loop() error {
 		previousInput := t.input
 		previousCx := t.cx
 		previousVersion := t.version
+		previousViMode := t.viMode()
+		if sequenceExpired {
+			sequenceTimer = nil
+			actions = t.flushKeySequence()
+			if len(actions) == 0 {
+				if string(previousInput) == string(t.input) && previousCx == t.cx && previousViMode == t.viMode() {
+					t.mutex.Unlock()
+					t.reqBox.Set(reqInfo, nil)
+					continue
+				}
+				// The keys are handled by the normal mode of vi
+				actions = []*action{{t: actIgnore}}
+			}
+			req(reqInfo)
+		}
 		if event.Type < tui.Invalid {
 			t.lastKey = event.KeyName()
 			t.lastActivity = time.Now()
+			t.resetTimers(tui.Idle)
 		}
 		updatePreviewWindow := func(forcePreview bool) {
 			t.resizeWindows(forcePreview, false)
@@ -6654,6 +7005,7 @@ func (t *Terminal) Loop() error {
 			//   actions to allow changing the query even when the input is hidden
 			//     e.g. fzf --no-input --bind 'space:show-input+change-query(foo)+hide-input'
 			currentInput := t.input
+			previousState := t.queryState()
 			capture := func(firstLineOnly bool, callback func(string)) {
 				if a.t >= actBgTransform {
 					// bg-transform-*
@@ -6697,12 +7049,17 @@ func (t *Terminal) Loop() error {
 						t.history.append(string(t.input))
 					}
 
+					// The server is gone, so is the certificate
+					environ := slices.DeleteFunc(t.environ(), func(e string) bool {
+						return strings.HasPrefix(e, "FZF_TLS_CERT=")
+					})
 					if len(t.proxyScript) > 0 {
-						data := strings.Join(append([]string{command}, t.environ()...), "\x00")
+						data := strings.Join(append([]string{command}, environ...), "\x00")
 						os.WriteFile(t.proxyScript+becomeSuffix, []byte(data), 0600)
 						req(reqBecome)
 					} else {
-						t.executor.Become(t.ttyin, t.environ(), command)
+						t.removeCertFile()
+						t.executor.Become(t.ttyin, environ, command)
 					}
 				}
 			case actBell:
@@ -6738,7 +7095,9 @@ func (t *Terminal) Loop() error {
 			case actBracketedPasteBegin:
 				current := []rune(t.input)
 				t.pasting = &current
+				t.undoHistory.breakCoalesce()
 			case actBracketedPasteEnd:
+				t.undoHistory.breakCoalesce()
 				if t.pasting != nil {
 					queryChanged = string(t.input) != string(*t.pasting)
 					t.pasting = nil
@@ -6909,7 +7268,7 @@ func (t *Terminal) Loop() error {
 					}
 					if withNthExpr != t.withNthExpr {
 						if factory, err := nthTransformer(withNthExpr); err == nil {
-							newWithNth = &withNthSpec{fn: factory(t.delimiter)}
+							newWithNth = &withNthSpec{fn: factory(t.delimiter, t.sources.name)}
 						} else {
 							return
 						}
@@ -7450,6 +7809,37 @@ func (t *Terminal) Loop() error {
 					t.input = trimQuery(t.history.next())
 					t.cx = len(t.input)
 				}
+			case actSearchHistory:
+				if t.history != nil {
+					t.historySearch = newHistorySearch(t.history.entries(), t.historyPattern)
+					req(reqPrompt, reqList, reqInfo)
+				}
+			case actStartMacro:
+				t.macroRecording = &macroRecording{name: a.a}
+			case actStopMacro:
+				if t.macroRecording != nil {
+					t.macroError = t.macros.set(t.macroRecording.name, t.macroRecording.actions)
+					t.macroRecording = nil
+					req(reqInfo)
+				}
+			case actPlayMacro:
+				// Recursive playback is not allowed
+				if actions, found := t.macros.get(a.a); found && !t.playingMacros[a.a] {
+					t.playingMacros[a.a] = true
+					done := !doActions(actions)
+					delete(t.playingMacros, a.a)
+					if done {
+						return false
+					}
+				}
+			case actUndo, actRedo:
+				undo := t.undoHistory.undo
+				if a.t == actRedo {
+					undo = t.undoHistory.redo
+				}
+				if state, ok := undo(t.queryState()); ok && t.restoreQueryState(state) {
+					changed = true
+				}
 			case actToggleSearch:
 				t.paused = !t.paused
 				changed = !t.paused
@@ -7906,20 +8296,40 @@ func (t *Terminal) Loop() error {
 						return doActions(actionsFor(tui.ClickHeader))
 					}
 				}
-			case actReload, actReloadSync:
+			case actReload, actReloadSync, actReloadSource:
 				t.failed = nil
 
-				valid, list := t.buildPlusList(a.a, false)
-				if !valid {
-					// We run the command even when there's no match
-					// 1. If the template doesn't have any slots
-					// 2. If the template has {q}
-					slot, _, _, forceUpdate := hasPreviewFlags(a.a)
-					valid = !slot || forceUpdate
+				valid := true
+				if a.t == actReloadSource {
+					// The source command is run verbatim without placeholders
+					id := t.sources.find(a.a)
+					if id < 0 {
+						break
+					}
+					newCommand = &commandSpec{t.sources.sources[id].command, nil, a.a}
+					t.keptSelections = make(map[int32]selectedItem)
+					for index, sel := range t.selected {
+						if t.sources.lookup(index) != id {
+							t.keptSelections[index] = sel
+						}
+					}
+				} else {
+					var list [3][]*Item
+					valid, list = t.buildPlusList(a.a, false)
+					if !valid {
+						// We run the command even when there's no match
+						// 1. If the template doesn't have any slots
+						// 2. If the template has {q}
+						slot, _, _, forceUpdate := hasPreviewFlags(a.a)
+						valid = !slot || forceUpdate
+					}
+					if valid {
+						command, tempFiles := t.replacePlaceholder(a.a, false, string(t.input), list)
+						newCommand = &commandSpec{command, tempFiles, ""}
+						t.keptSelections = nil
+					}
 				}
 				if valid {
-					command, tempFiles := t.replacePlaceholder(a.a, false, string(t.input), list)
-					newCommand = &commandSpec{command, tempFiles}
 					reloadSync = a.t == actReloadSync
 					t.reading = true
 
@@ -7940,21 +8350,29 @@ func (t *Terminal) Loop() error {
 					}
 				}
 			case actUnbind:
-				if keys, _, err := parseKeyChords(a.a, "PANIC"); err == nil {
+				if keys, sequences, err := parseBindTargets(a.a, "PANIC"); err == nil {
 					for key := range keys {
 						delete(t.keymap, key)
 					}
+					for _, sequence := range sequences {
+						delete(t.sequences, sequence)
+					}
 				}
 			case actRebind:
-				if keys, _, err := parseKeyChords(a.a, "PANIC"); err == nil {
+				if keys, sequences, err := parseBindTargets(a.a, "PANIC"); err == nil {
 					for key := range keys {
 						if originalAction, found := t.keymapOrg[key]; found {
 							t.keymap[key] = originalAction
 						}
 					}
+					for _, sequence := range sequences {
+						if originalAction, found := t.sequencesOrg[sequence]; found {
+							t.sequences[sequence] = originalAction
+						}
+					}
 				}
 			case actToggleBind:
-				if keys, _, err := parseKeyChords(a.a, "PANIC"); err == nil {
+				if keys, sequences, err := parseBindTargets(a.a, "PANIC"); err == nil {
 					for key := range keys {
 						if _, bound := t.keymap[key]; bound {
 							delete(t.keymap, key)
@@ -7962,6 +8380,13 @@ func (t *Terminal) Loop() error {
 							t.keymap[key] = originalAction
 						}
 					}
+					for _, sequence := range sequences {
+						if _, bound := t.sequences[sequence]; bound {
+							delete(t.sequences, sequence)
+						} else if originalAction, found := t.sequencesOrg[sequence]; found {
+							t.sequences[sequence] = originalAction
+						}
+					}
 				}
 			case actChangeGhost, actTransformGhost, actBgTransformGhost:
 				capture(true, func(ghost string) {
@@ -8071,13 +8496,44 @@ func (t *Terminal) Loop() error {
 				t.input = currentInput
 				t.cx = len(t.input)
 				beof = false
-			} else if string(t.input) != string(currentInput) {
+			} else if string(t.input) != string(currentInput) && a.t != actUndo && a.t != actRedo {
 				t.inputOverride = nil
 			}
+			if a.t != actUndo && a.t != actRedo {
+				t.recordQueryChange(previousState, a.t == actChar)
+			}
 			return true
 		}
 
-		if t.jumping == jumpDisabled || len(actions) > 0 {
+		if t.historySearch != nil && len(actions) == 0 {
+			// Keys are handled by the history search overlay until it is closed
+			if done, entry := t.updateHistorySearch(event); done {
+				t.historySearch = nil
+				if entry != nil {
+					previousState := t.queryState()
+					t.input = trimQuery(*entry)
+					t.cx = len(t.input)
+					t.recordQueryChange(previousState, false)
+				}
+				queryChanged = string(previousInput) != string(t.input)
+				changed = queryChanged
+				if queryChanged {
+					t.restartChangeTimers()
+				}
+				if onChanges, prs := t.keymap[tui.Change.AsEvent()]; queryChanged && prs && !doActions(onChanges) {
+					continue
+				}
+				if queryChanged {
+					t.publishEvent("change")
+				}
+			}
+			req(reqPrompt, reqList, reqInfo)
+		} else if t.jumping == jumpDisabled || len(actions) > 0 {
+			// Close the history search overlay if any action is submitted to the server
+			if t.historySearch != nil {
+				t.historySearch = nil
+				req(reqPrompt, reqList, reqInfo)
+			}
 			// Break out of jump mode if any action is submitted to the server
 			if t.jumping != jumpDisabled {
 				t.jumping = jumpDisabled
@@ -8086,10 +8542,50 @@ func (t *Terminal) Loop() error {
 				}
 				req(reqList)
 			}
+			consumed := false
+			viConsumed := false
 			if len(actions) == 0 {
-				actions = t.keymap[event.Comparable()]
+				wasPending := len(t.pendingKeys) > 0
+				var sequenceActions []*action
+				consumed, sequenceActions = t.extendKeySequence(event)
+				if !consumed && t.vi != nil {
+					// The key can also be the one that broke a pending sequence
+					var viActions []*action
+					consumed, viActions = t.viKeyWithUndo(event)
+					viConsumed = consumed
+					sequenceActions = slices.Concat(sequenceActions, viActions)
+				}
+				if len(t.pendingKeys) > 0 {
+					sequenceTimer = time.After(t.sequenceTimeout)
+				} else {
+					sequenceTimer = nil
+				}
+				if wasPending || len(t.pendingKeys) > 0 {
+					req(reqInfo)
+				}
+				if consumed {
+					actions = sequenceActions
+				} else {
+					actions = t.keymap[event.Comparable()]
+					if len(sequenceActions) > 0 {
+						// Perform the actions for the abandoned sequence first
+						if len(actions) == 0 && event.Type == tui.Rune {
+							actions = []*action{{t: actChar}}
+						}
+						actions = slices.Concat(sequenceActions, actions)
+					}
+				}
+			}
+			// Record the actions triggered by the keyboard. The keys handled by
+			// the vi normal mode are not recorded as they depend on its state.
+			if t.macroRecording != nil && event.Type < tui.Mouse && !viConsumed {
+				if len(actions) == 0 && event.Type == tui.Rune && !consumed {
+					t.macroRecording.record([]*action{{t: actChar}}, event.Char)
+				} else {
+					t.macroRecording.record(actions, event.Char)
+				}
 			}
-			if len(actions) == 0 && event.Type == tui.Rune {
+			if len(actions) == 0 && event.Type == tui.Rune && !consumed {
 				doAction(&action{t: actChar})
 			} else if !doActions(actions) {
 				continue
@@ -8099,6 +8595,9 @@ func (t *Terminal) Loop() error {
 			}
 			queryChanged = queryChanged || t.pasting == nil && string(previousInput) != string(t.input)
 			changed = changed || queryChanged
+			if queryChanged {
+				t.restartChangeTimers()
+			}
 			if onChanges, prs := t.keymap[tui.Change.AsEvent()]; queryChanged && prs && !doActions(onChanges) {
 				continue
 			}
@@ -8108,6 +8607,22 @@ func (t *Terminal) Loop() error {
 			if onMultis, prs := t.keymap[tui.Multi.AsEvent()]; t.version != previousVersion && prs && !doActions(onMultis) {
 				continue
 			}
+			if mode := t.viMode(); mode != previousViMode {
+				req(reqPrompt)
+				modeEvent := tui.InsertMode
+				if mode == viNormal {
+					modeEvent = tui.NormalMode
+				}
+				if onModes, prs := t.keymap[modeEvent.AsEvent()]; prs && !doActions(onModes) {
+					continue
+				}
+			}
+			if queryChanged {
+				t.publishEvent("change")
+			}
+			if t.version != previousVersion {
+				t.publishEvent("selection")
+			}
 		} else {
 			jumpEvent := tui.JumpCancel
 			if event.Type == tui.Rune {
@@ -8140,7 +8655,18 @@ func (t *Terminal) Loop() error {
 		reload := changed || newCommand != nil
 		var reloadRequest *searchRequest
 		if reload {
-			reloadRequest = &searchRequest{sort: t.sort, sync: reloadSync, nth: newNth, withNth: newWithNth, headerLines: newHeaderLines, command: newCommand, environ: t.environ(), changed: changed, denylist: denylist, revision: t.resultMerger.Revision()}
+			t.searchSeq++
+			reloadRequest = &searchRequest{sort: t.sort, sync: reloadSync, nth: newNth, withNth: newWithNth, headerLines: newHeaderLines, command: newCommand, environ: t.environ(), changed: changed, denylist: denylist, revision: t.resultMerger.Revision(), seq: t.searchSeq}
+		}
+
+		// Let the client of the server know when the actions take effect
+		if syncDone != nil {
+			if reload {
+				t.syncWaiters = append(t.syncWaiters, syncWaiter{t.searchSeq, newCommand != nil, syncDone})
+			} else {
+				close(syncDone)
+			}
+			syncDone = nil
 		}
 
 		// Dispatch queued background requests
@@ -8311,7 +8837,7 @@ func (t *Terminal) maxItems() int {
 	return max(maximum, 0)
 }
 
-func (t *Terminal) dumpItem(i *Item) StatusItem {
+func (t *Terminal) dumpItem(i *Item, fields map[string]bool) StatusItem {
 	if i == nil {
 		return StatusItem{}
 	}
@@ -8320,15 +8846,52 @@ func (t *Terminal) dumpItem(i *Item) StatusItem {
 		Text:  i.AsString(t.ansi),
 	}
 	if t.resultMerger.pattern != nil {
-		_, _, pos := t.resultMerger.pattern.MatchItem(i, true, t.slab)
+		result, _, pos, score := t.resultMerger.pattern.matchItem(i, true, t.slab)
 		if pos != nil {
 			sort.Ints(*pos)
 			item.Positions = *pos
 		}
+		if fields["score"] && result.item != nil {
+			item.Score = &score
+		}
+	}
+	if fields["display"] {
+		display := i.text.ToString()
+		item.Display = &display
+	}
+	if fields["output"] {
+		output := item.Text
+		if t.acceptNth != nil {
+			output = i.acceptNth(t.ansi, t.delimiter, t.acceptNth)
+		}
+		item.Output = &output
+	}
+	if fields["selected"] {
+		_, selected := t.selected[i.Index()]
+		item.Selected = &selected
 	}
 	return item
 }
 
+// publishEvent sends the current state to the clients of the event stream.
+// t.mutex should be locked by the caller.
+func (t *Terminal) publishEvent(name string) {
+	if !t.eventStream.active() {
+		return
+	}
+	event := StatusEvent{Event: name, Status: t.status(), SelectedCount: len(t.selected)}
+	if currentItem := t.currentItem(); currentItem != nil {
+		item := t.dumpItem(currentItem, nil)
+		event.Current = &item
+	}
+	if name == "accept" {
+		for _, sel := range t.sortSelected() {
+			event.Selected = append(event.Selected, t.dumpItem(sel.item, nil))
+		}
+	}
+	t.eventStream.publish(event)
+}
+
 func (t *Terminal) tryLock(timeout time.Duration) bool {
 	sleepDuration := 10 * time.Millisecond
 
@@ -8346,41 +8909,81 @@ func (t *Terminal) tryLock(timeout time.Duration) bool {
 	return false
 }
 
+// status returns the Status with the basic fields filled in.
+// t.mutex should be locked by the caller.
+func (t *Terminal) status() Status {
+	return Status{
+		Reading:    t.reading,
+		Progress:   t.progress,
+		Query:      string(t.input),
+		Position:   t.cy,
+		Sort:       t.sort,
+		TotalCount: t.count,
+		MatchCount: t.resultMerger.Length(),
+	}
+}
+
 func (t *Terminal) dumpStatus(params getParams) string {
 	if !t.tryLock(channelTimeout) {
 		return ""
 	}
 	defer t.mutex.Unlock()
 
+	fields := params.fields
 	selectedItems := t.sortSelected()
 	selected := make([]StatusItem, max(0, min(params.limit, len(selectedItems)-params.offset)))
 	for i := range selected {
-		selected[i] = t.dumpItem(selectedItems[i+params.offset].item)
+		selected[i] = t.dumpItem(selectedItems[i+params.offset].item, fields)
 	}
 
-	matches := make([]StatusItem, max(0, min(params.limit, t.resultMerger.Length()-params.offset)))
-	for i := range matches {
-		matches[i] = t.dumpItem(t.resultMerger.Get(i + params.offset).item)
+	var matches []StatusItem
+	if params.selectedOnly {
+		matches = []StatusItem{}
+		skip := params.offset
+		for i := 0; i < t.resultMerger.Length() && len(matches) < params.limit; i++ {
+			item := t.resultMerger.Get(i).item
+			if _, found := t.selected[item.Index()]; !found {
+				continue
+			}
+			if skip > 0 {
+				skip--
+				continue
+			}
+			matches = append(matches, t.dumpItem(item, fields))
+		}
+	} else {
+		matches = make([]StatusItem, max(0, min(params.limit, t.resultMerger.Length()-params.offset)))
+		for i := range matches {
+			matches[i] = t.dumpItem(t.resultMerger.Get(i+params.offset).item, fields)
+		}
 	}
 
 	var current *StatusItem
 	currentItem := t.currentItem()
 	if currentItem != nil {
-		item := t.dumpItem(currentItem)
+		item := t.dumpItem(currentItem, fields)
 		current = &item
 	}
 
-	dump := Status{
-		Reading:    t.reading,
-		Progress:   t.progress,
-		Query:      string(t.input),
-		Position:   t.cy,
-		Sort:       t.sort,
-		TotalCount: t.count,
-		MatchCount: t.resultMerger.Length(),
-		Current:    current,
-		Matches:    matches,
-		Selected:   selected,
+	dump := t.status()
+	dump.Current = current
+	dump.Matches = matches
+	dump.Selected = selected
+	if fields["prompt"] {
+		dump.Prompt = &t.promptString
+	}
+	if fields["nth"] {
+		nth := RangesToString(t.nthCurrent)
+		dump.Nth = &nth
+	}
+	if fields["header"] {
+		for _, line := range t.header0 {
+			trimmed, _, _ := extractColor(line, nil, nil)
+			dump.Header = append(dump.Header, trimmed)
+		}
+		for _, item := range t.header {
+			dump.HeaderLines = append(dump.HeaderLines, item.text.ToString())
+		}
 	}
 	bytes, _ := json.Marshal(&dump) // TODO: Errors?
 	return string(bytes)
@@ -8,7 +8,9 @@ import (
 	"strings"
 	"testing"
 	"text/template"
+	"time"
 
+	"github.com/junegunn/fzf/src/tui"
 	"github.com/junegunn/fzf/src/util"
 )
 
@@ -768,3 +770,30 @@ func TestWordWrapAnsiLine(t *testing.T) {
 		t.Errorf("Tab wrap: %q", result)
 	}
 }
+
+func TestSyncRequestEndingEarly(t *testing.T) {
+	opts, err := ParseOptions(false, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := postProcessOptions(opts); err != nil {
+		t.Fatal(err)
+	}
+	opts.renderer = tui.NewHeadlessRenderer(30, 8, opts.Tabstop, nil)
+	term, err := NewTerminal(opts, util.NewEventBox(), util.NewExecutor(""), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	go term.Loop()
+	term.startChan <- fitpad{-1, -1}
+
+	// The actions end the iteration of the loop early, but the client should
+	// still be notified
+	done := make(chan struct{})
+	term.serverInputChan <- serverRequest{[]*action{{t: actInvalid}}, done}
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Error("sync request not completed")
+	}
+}
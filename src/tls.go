@@ -0,0 +1,144 @@
+package fzf
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"net"
+	"os"
+	"strings"
+	"time"
+)
+
+const selfSignedValidity = 30 * 24 * time.Hour
+
+// tlsOpts is the certificate and the key for --listen-tls. A self-signed
+// certificate is generated if they are not given.
+type tlsOpts struct {
+	certFile string
+	keyFile  string
+}
+
+func parseTLSOpts(str string) (tlsOpts, error) {
+	if len(str) == 0 {
+		return tlsOpts{}, nil
+	}
+	parts := strings.Split(str, ",")
+	if len(parts) != 2 || len(parts[0]) == 0 || len(parts[1]) == 0 {
+		return tlsOpts{}, errors.New("invalid TLS options (expected: CERT_FILE,KEY_FILE)")
+	}
+	return tlsOpts{parts[0], parts[1]}, nil
+}
+
+func (opts tlsOpts) selfSigned() bool {
+	return len(opts.certFile) == 0
+}
+
+// serverConfig returns the TLS configuration of the server along with the
+// path to the certificate. The certificate of a self-signed pair is written
+// to a temporary file so that the clients can trust it.
+func (opts tlsOpts) serverConfig(host string) (*tls.Config, string, error) {
+	if !opts.selfSigned() {
+		cert, err := tls.LoadX509KeyPair(opts.certFile, opts.keyFile)
+		if err != nil {
+			return nil, "", errors.New("failed to load TLS certificate: " + err.Error())
+		}
+		return &tls.Config{Certificates: []tls.Certificate{cert}, MinVersion: tls.VersionTLS12}, opts.certFile, nil
+	}
+
+	certPEM, keyPEM, err := generateCertificate(host)
+	if err != nil {
+		return nil, "", err
+	}
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		return nil, "", err
+	}
+	f, err := os.CreateTemp("", "fzf-cert-*.pem")
+	if err != nil {
+		return nil, "", err
+	}
+	defer f.Close()
+	if _, err := f.Write(certPEM); err != nil {
+		os.Remove(f.Name())
+		return nil, "", err
+	}
+	return &tls.Config{Certificates: []tls.Certificate{cert}, MinVersion: tls.VersionTLS12}, f.Name(), nil
+}
+
+// generateCertificate generates a self-signed certificate for localhost, the
+// hostname of the machine, and the given host
+func generateCertificate(host string) ([]byte, []byte, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, nil, err
+	}
+	now := time.Now()
+	template := x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{CommonName: "fzf"},
+		NotBefore:             now.Add(-time.Minute),
+		NotAfter:              now.Add(selfSignedValidity),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		DNSNames:              []string{"localhost"},
+		IPAddresses:           []net.IP{net.IPv4(127, 0, 0, 1), net.IPv6loopback},
+	}
+	names := []string{host}
+	if hostname, err := os.Hostname(); err == nil {
+		names = append(names, hostname)
+	}
+	for _, name := range names {
+		if len(name) == 0 || name == "localhost" {
+			continue
+		}
+		if ip := net.ParseIP(name); ip != nil {
+			if !ip.IsUnspecified() {
+				template.IPAddresses = append(template.IPAddresses, ip)
+			}
+		} else {
+			template.DNSNames = append(template.DNSNames, name)
+		}
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, &template, &template, &key.PublicKey, key)
+	if err != nil {
+		return nil, nil, err
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+	return certPEM, keyPEM, nil
+}
+
+// clientTLSConfig returns the TLS configuration that trusts the certificate
+// in the given file in addition to the system certificates
+func clientTLSConfig(certFile string, host string) (*tls.Config, error) {
+	data, err := os.ReadFile(certFile)
+	if err != nil {
+		return nil, errors.New("failed to read TLS certificate: " + certFile)
+	}
+	pool, err := x509.SystemCertPool()
+	if err != nil {
+		pool = x509.NewCertPool()
+	}
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, errors.New("invalid TLS certificate: " + certFile)
+	}
+	return &tls.Config{RootCAs: pool, ServerName: host, MinVersion: tls.VersionTLS12}, nil
+}
@@ -0,0 +1,126 @@
+package fzf
+
+import (
+	"bufio"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestParseTLSOpts(t *testing.T) {
+	if opts, err := parseTLSOpts(""); err != nil || !opts.selfSigned() {
+		t.Errorf("unexpected result: %v, %v", opts, err)
+	}
+	if opts, err := parseTLSOpts("cert.pem,key.pem"); err != nil || opts.certFile != "cert.pem" || opts.keyFile != "key.pem" {
+		t.Errorf("unexpected result: %v, %v", opts, err)
+	}
+	for _, str := range []string{"cert.pem", "cert.pem,", ",key.pem", "a,b,c"} {
+		if _, err := parseTLSOpts(str); err == nil {
+			t.Errorf("should fail: %s", str)
+		}
+	}
+}
+
+func TestGenerateCertificate(t *testing.T) {
+	certPEM, _, err := generateCertificate("192.168.0.10")
+	if err != nil {
+		t.Fatal(err)
+	}
+	block, _ := pem.Decode(certPEM)
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, host := range []string{"localhost", "127.0.0.1", "192.168.0.10"} {
+		if err := cert.VerifyHostname(host); err != nil {
+			t.Errorf("certificate should be valid for %s: %v", host, err)
+		}
+	}
+}
+
+func TestTLSServer(t *testing.T) {
+	config, certFile, err := tlsOpts{}.serverConfig("localhost")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(certFile)
+
+	listener, port, err := startHttpServer(listenAddress{"localhost", 0, ""}, config, make(chan serverRequest), func(getParams) string {
+		return `{"query":"foo"}`
+	}, newEventStream(), nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+
+	// Connect with the exported certificate
+	t.Setenv("FZF_TLS_CERT", certFile)
+	conn, err := remoteDial(listenAddress{"localhost", port, ""})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	conn.Write([]byte(remoteRequest(nil, "")))
+	if code, body, err := parseResponse(bufio.NewReader(conn)); err != nil || code != 200 || body != `{"query":"foo"}`+"\n" {
+		t.Errorf("unexpected response: %d, %q, %v", code, body, err)
+	}
+
+	// Plain TCP request should not be served
+	response := httpRequest(t, port, "GET / HTTP/1.1\r\n\r\n")
+	if strings.Contains(response, "foo") {
+		t.Errorf("unexpected response: %q", response)
+	}
+
+	// Untrusted certificate
+	other, otherFile, _ := tlsOpts{}.serverConfig("localhost")
+	defer os.Remove(otherFile)
+	if other == nil {
+		t.Fatal("failed to generate certificate")
+	}
+	t.Setenv("FZF_TLS_CERT", otherFile)
+	if conn, err := remoteDial(listenAddress{"localhost", port, ""}); err == nil {
+		conn.Close()
+		t.Error("should not trust the certificate")
+	}
+}
+
+func TestTLSServerName(t *testing.T) {
+	config, certFile, err := tlsOpts{}.serverConfig("fzf-test.example")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(certFile)
+
+	listener, port, err := startHttpServer(listenAddress{"localhost", 0, ""}, config, make(chan serverRequest), func(getParams) string {
+		return ""
+	}, newEventStream(), nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+
+	t.Setenv("FZF_TLS_CERT", certFile)
+	for name, valid := range map[string]bool{"fzf-test.example": true, "other.example": false} {
+		t.Setenv("FZF_TLS_SERVER_NAME", name)
+		conn, err := remoteDial(listenAddress{"localhost", port, ""})
+		if err == nil {
+			// Handshake is performed on the first write
+			_, err = conn.Write([]byte(remoteRequest(nil, "")))
+			conn.Close()
+		}
+		if (err == nil) != valid {
+			t.Errorf("server name %s: %v", name, err)
+		}
+	}
+}
+
+func TestRemoteAccessRequiresTLS(t *testing.T) {
+	t.Setenv("FZF_API_KEY", "secret")
+	t.Setenv("FZF_API_KEYS", "")
+	_, _, err := startHttpServer(listenAddress{"127.0.0.2", 0, ""}, nil, make(chan serverRequest), func(getParams) string { return "" }, newEventStream(), nil, nil, nil)
+	if err == nil || !strings.Contains(err.Error(), "--listen-tls") {
+		t.Errorf("should require TLS: %v", err)
+	}
+}
@@ -76,6 +76,21 @@ func (t Token) String() string {
 type Delimiter struct {
 	regex *regexp.Regexp
 	str   *string
+	csv   bool // str is a single-byte separator with RFC 4180 quoting
+}
+
+// csvDelimiter returns a delimiter for CSV-style input separated by sep
+func csvDelimiter(sep string) Delimiter {
+	return Delimiter{str: &sep, csv: true}
+}
+
+// csvSeparator returns the separator of the CSV delimiter, or 0 if the
+// delimiter is not a CSV delimiter
+func (d Delimiter) csvSeparator() byte {
+	if !d.csv {
+		return 0
+	}
+	return (*d.str)[0]
 }
 
 // IsAwk returns true if the delimiter is an AWK-style delimiter
@@ -85,7 +100,7 @@ func (d Delimiter) IsAwk() bool {
 
 // String returns the string representation of a Delimiter.
 func (d Delimiter) String() string {
-	return fmt.Sprintf("Delimiter{regex: %v, str: &%q}", d.regex, *d.str)
+	return fmt.Sprintf("Delimiter{regex: %v, str: &%q, csv: %v}", d.regex, *d.str, d.csv)
 }
 
 func newRange(begin int, end int) Range {
@@ -189,6 +204,86 @@ func awkTokenizer(input string) ([]string, int) {
 	return ret, prefixLength
 }
 
+// csvTokenizer splits the input on sep while honoring RFC 4180 quoting.
+// Like strings.SplitAfter, each token retains its trailing separator and
+// the quotes are left intact so that the prefix lengths match the input.
+func csvTokenizer(input string, sep byte) []string {
+	ret := []string{}
+	begin := 0
+	quoted := false
+	fieldStart := true
+	for idx := 0; idx < len(input); idx++ {
+		c := input[idx]
+		switch {
+		case quoted:
+			if c == '"' {
+				if idx+1 < len(input) && input[idx+1] == '"' {
+					idx++ // Escaped quote
+				} else {
+					quoted = false
+				}
+			}
+		case c == sep:
+			ret = append(ret, input[begin:idx+1])
+			begin = idx + 1
+			fieldStart = true
+			continue
+		case c == '"' && fieldStart:
+			quoted = true
+		}
+		fieldStart = false
+	}
+	return append(ret, input[begin:])
+}
+
+// csvUnquote returns the value of a CSV field with the surrounding quotes
+// and the trailing separator removed, and the escaped quotes unescaped.
+func csvUnquote(field string, sep byte) string {
+	var output strings.Builder
+	quoted := false
+	for idx := 0; idx < len(field); idx++ {
+		c := field[idx]
+		switch {
+		case quoted:
+			if c != '"' {
+				output.WriteByte(c)
+			} else if idx+1 < len(field) && field[idx+1] == '"' {
+				output.WriteByte(c)
+				idx++
+			} else {
+				quoted = false
+			}
+		case c == sep:
+			return output.String()
+		case c == '"' && idx == 0:
+			quoted = true
+		default:
+			output.WriteByte(c)
+		}
+	}
+	return output.String()
+}
+
+// UnquoteTokens returns the tokens with the CSV quoting removed from each
+// field. The separators are retained. Tokens are returned as-is unless the
+// delimiter is a CSV delimiter.
+func UnquoteTokens(tokens []Token, delimiter Delimiter) []Token {
+	if !delimiter.csv {
+		return tokens
+	}
+	sep := (*delimiter.str)[0]
+	ret := make([]Token, len(tokens))
+	for idx, token := range tokens {
+		str := csvUnquote(token.text.ToString(), sep)
+		if idx < len(tokens)-1 {
+			str += string(sep)
+		}
+		chars := util.ToChars(stringBytes(str))
+		ret[idx] = Token{&chars, token.prefixLength}
+	}
+	return ret
+}
+
 // Tokenize tokenizes the given string with the delimiter
 func Tokenize(text string, delimiter Delimiter) []Token {
 	if delimiter.str == nil && delimiter.regex == nil {
@@ -197,6 +292,10 @@ func Tokenize(text string, delimiter Delimiter) []Token {
 		return withPrefixLengths(tokens, prefixLength)
 	}
 
+	if delimiter.csv {
+		return withPrefixLengths(csvTokenizer(text, (*delimiter.str)[0]), 0)
+	}
+
 	if delimiter.str != nil {
 		return withPrefixLengths(strings.SplitAfter(text, *delimiter.str), 0)
 	}
@@ -78,6 +78,36 @@ func TestTokenize(t *testing.T) {
 	}
 }
 
+func TestTokenizeCSV(t *testing.T) {
+	input := `a,"b,c","say ""hi""",,"x
+y"`
+	tokens := Tokenize(input, csvDelimiter(","))
+	expected := []string{`a,`, `"b,c",`, `"say ""hi""",`, `,`, "\"x\ny\""}
+	if len(tokens) != len(expected) {
+		t.Fatalf("%s", tokens)
+	}
+	prefixLength := 0
+	for idx, token := range tokens {
+		if token.text.ToString() != expected[idx] || int(token.prefixLength) != prefixLength {
+			t.Errorf("%d: %s", idx, token)
+		}
+		prefixLength += len(expected[idx])
+	}
+
+	unquoted := []string{`a,`, `b,c,`, `say "hi",`, `,`, "x\ny"}
+	for idx, token := range UnquoteTokens(tokens, csvDelimiter(",")) {
+		if token.text.ToString() != unquoted[idx] {
+			t.Errorf("%d: %s", idx, token)
+		}
+	}
+
+	// Quotes in the middle of an unquoted field are literal
+	tokens = Tokenize("a\tb\"c\td", csvDelimiter("\t"))
+	if len(tokens) != 3 || tokens[1].text.ToString() != "b\"c\t" {
+		t.Errorf("%s", tokens)
+	}
+}
+
 func TestTransform(t *testing.T) {
 	input := "  abc:  def:  ghi:  jkl"
 	{
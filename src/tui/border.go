@@ -0,0 +1,145 @@
+package tui
+
+// paintHLine fills row `row` with `line` between optional left/right caps.
+// A zero rune means "no cap"; caps are placed at the very edges of `w`.
+func paintHLine(w Window, row int, line, leftCap, rightCap rune, color ColorPair) {
+	w.Move(row, 0)
+	hw := runeWidth(line)
+	width := w.Width()
+	if leftCap != 0 {
+		w.CPrint(color, string(leftCap))
+		width -= runeWidth(leftCap)
+	}
+	if rightCap != 0 {
+		width -= runeWidth(rightCap)
+	}
+	if width < 0 {
+		width = 0
+	}
+	inner := width / hw
+	rem := width - inner*hw
+	w.CPrint(color, repeat(line, inner)+repeat(' ', rem))
+	if rightCap != 0 {
+		w.CPrint(color, string(rightCap))
+	}
+}
+
+// paintHSeparator draws an inline horizontal separator at the row of the window
+func paintHSeparator(w Window, border BorderStyle, row int, windowType WindowType, useBottom bool) {
+	if w.Height() == 0 {
+		return
+	}
+	shape := border.shape
+	if shape == BorderNone {
+		return
+	}
+	color := BorderColor(windowType)
+	line := border.top
+	if useBottom {
+		line = border.bottom
+	}
+	var leftCap, rightCap rune
+	if shape.HasLeft() {
+		leftCap = border.leftMid
+	}
+	if shape.HasRight() {
+		rightCap = border.rightMid
+	}
+	paintHLine(w, row, line, leftCap, rightCap, color)
+}
+
+// paintSectionFrame overpaints the border cells around the rows of a section
+func paintSectionFrame(w Window, border BorderStyle, topContent, bottomContent int, windowType WindowType, edge SectionEdge) {
+	if w.Height() == 0 || border.shape == BorderNone {
+		return
+	}
+	color := BorderColor(windowType)
+	shape := border.shape
+	hasLeft := shape.HasLeft()
+	hasRight := shape.HasRight()
+	rightW := runeWidth(border.right)
+	// Content rows: overpaint left/right verticals + their 1-char margin.
+	for row := topContent; row <= bottomContent; row++ {
+		if hasLeft {
+			w.Move(row, 0)
+			w.CPrint(color, string(border.left)+" ")
+		}
+		if hasRight {
+			w.Move(row, w.Width()-rightW-1)
+			w.CPrint(color, " "+string(border.right))
+		}
+	}
+	if edge == SectionEdgeTop && shape.HasTop() {
+		var leftCap, rightCap rune
+		if hasLeft {
+			leftCap = border.topLeft
+		}
+		if hasRight {
+			rightCap = border.topRight
+		}
+		paintHLine(w, 0, border.top, leftCap, rightCap, color)
+	}
+	if edge == SectionEdgeBottom && shape.HasBottom() {
+		var leftCap, rightCap rune
+		if hasLeft {
+			leftCap = border.bottomLeft
+		}
+		if hasRight {
+			rightCap = border.bottomRight
+		}
+		paintHLine(w, w.Height()-1, border.bottom, leftCap, rightCap, color)
+	}
+}
+
+// paintBorder draws the border of the window with Move and CPrint, so that it
+// can be shared by the renderers that don't have their own screen buffer
+func paintBorder(w Window, border BorderStyle, windowType WindowType, onlyHorizontal bool) {
+	if w.Height() == 0 {
+		return
+	}
+	shape := border.shape
+	if shape == BorderNone {
+		return
+	}
+	color := BorderColor(windowType)
+	hasLeft := shape.HasLeft()
+	hasRight := shape.HasRight()
+
+	if shape.HasTop() {
+		var leftCap, rightCap rune
+		if hasLeft {
+			leftCap = border.topLeft
+		}
+		if hasRight {
+			rightCap = border.topRight
+		}
+		paintHLine(w, 0, border.top, leftCap, rightCap, color)
+	}
+	if !onlyHorizontal && (hasLeft || hasRight) {
+		vw := runeWidth(border.left)
+		for y := 0; y < w.Height(); y++ {
+			// Corner rows are already painted by paintHLine above / below.
+			if (y == 0 && shape.HasTop()) || (y == w.Height()-1 && shape.HasBottom()) {
+				continue
+			}
+			if hasLeft {
+				w.Move(y, 0)
+				w.CPrint(color, string(border.left)+" ")
+			}
+			if hasRight {
+				w.Move(y, w.Width()-vw-1)
+				w.CPrint(color, " "+string(border.right))
+			}
+		}
+	}
+	if shape.HasBottom() {
+		var leftCap, rightCap rune
+		if hasLeft {
+			leftCap = border.bottomLeft
+		}
+		if hasRight {
+			rightCap = border.bottomRight
+		}
+		paintHLine(w, w.Height()-1, border.bottom, leftCap, rightCap, color)
+	}
+}
@@ -0,0 +1,387 @@
+package tui
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/junegunn/fzf/src/util"
+	"github.com/rivo/uniseg"
+)
+
+// HeadlessRenderer is a renderer that does not draw anything on the terminal.
+// Instead, it draws the windows on an in-memory grid of cells so that fzf can
+// run without a terminal, e.g. when replaying a recorded session, and so that
+// the tests can examine what would have been displayed.
+type HeadlessRenderer struct {
+	mutex         sync.Mutex
+	width         int
+	height        int
+	maxHeightFunc func(int) int
+	tabstop       int
+	cancel        chan struct{}
+	events        chan Event
+	cells         [][]Cell // Cells being drawn
+	screen        [][]Cell // Cells at the time of the last refresh
+}
+
+// Cell is a character cell of the grid of HeadlessRenderer. The second cell
+// of a wide character has an empty text.
+type Cell struct {
+	Text  string
+	Color ColorPair
+}
+
+var blankCell = Cell{" ", NewColorPair(colDefault, colDefault, AttrUndefined)}
+
+// NewHeadlessRenderer creates a headless renderer for a terminal of the given
+// size. maxHeightFunc determines the height of fzf from the height of the
+// terminal as in the light renderer.
+func NewHeadlessRenderer(width int, height int, tabstop int, maxHeightFunc func(int) int) *HeadlessRenderer {
+	return &HeadlessRenderer{
+		width:         width,
+		height:        height,
+		maxHeightFunc: maxHeightFunc,
+		tabstop:       tabstop,
+		cancel:        make(chan struct{}, 1),
+		events:        make(chan Event, 1024)}
+}
+
+// SetSize changes the size of the terminal
+func (r *HeadlessRenderer) SetSize(width int, height int) {
+	r.mutex.Lock()
+	r.width = width
+	r.height = height
+	r.mutex.Unlock()
+}
+
+func (r *HeadlessRenderer) DefaultTheme() *ColorTheme { return Default16 }
+func (r *HeadlessRenderer) Init() error               { return nil }
+func (r *HeadlessRenderer) Pause(bool)                {}
+func (r *HeadlessRenderer) Resume(bool, bool)         {}
+func (r *HeadlessRenderer) Refresh()                  {}
+func (r *HeadlessRenderer) Close()                    {}
+func (r *HeadlessRenderer) PassThrough(string)        {}
+func (r *HeadlessRenderer) NeedScrollbarRedraw() bool { return false }
+func (r *HeadlessRenderer) Bell()                     {}
+func (r *HeadlessRenderer) HideCursor()               {}
+func (r *HeadlessRenderer) ShowCursor()               {}
+func (r *HeadlessRenderer) Top() int                  { return 0 }
+
+// Clear clears the grid. The grid is resized to the current size of the
+// renderer.
+func (r *HeadlessRenderer) Clear() {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.cells = r.newGrid()
+}
+
+// RefreshWindows takes a snapshot of the grid. It is called at the end of
+// each rendering cycle.
+func (r *HeadlessRenderer) RefreshWindows([]Window) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.screen = make([][]Cell, len(r.cells))
+	for y, line := range r.cells {
+		r.screen[y] = append([]Cell{}, line...)
+	}
+}
+
+func (r *HeadlessRenderer) newGrid() [][]Cell {
+	height := r.height
+	if r.maxHeightFunc != nil {
+		height = r.maxHeightFunc(height)
+	}
+	grid := make([][]Cell, max(0, height))
+	for y := range grid {
+		grid[y] = make([]Cell, max(0, r.width))
+		for x := range grid[y] {
+			grid[y][x] = blankCell
+		}
+	}
+	return grid
+}
+
+// set puts the text at the given position of the grid. The text is expected
+// to be a single grapheme.
+func (r *HeadlessRenderer) set(y int, x int, text string, width int, color ColorPair) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if y < 0 || y >= len(r.cells) || x < 0 || x >= len(r.cells[y]) {
+		return
+	}
+	line := r.cells[y]
+	line[x] = Cell{text, color}
+	for i := 1; i < width && x+i < len(line); i++ {
+		line[x+i] = Cell{"", color}
+	}
+}
+
+// Snapshot returns the text on the screen at the time of the last refresh.
+// Trailing spaces of each line and trailing empty lines are removed.
+func (r *HeadlessRenderer) Snapshot() string {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	lines := make([]string, len(r.screen))
+	for y, line := range r.screen {
+		var builder strings.Builder
+		for _, cell := range line {
+			builder.WriteString(cell.Text)
+		}
+		lines[y] = strings.TrimRight(builder.String(), " ")
+	}
+	for len(lines) > 0 && len(lines[len(lines)-1]) == 0 {
+		lines = lines[:len(lines)-1]
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Cell returns the cell at the given position of the screen at the time of
+// the last refresh
+func (r *HeadlessRenderer) Cell(y int, x int) Cell {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if y < 0 || y >= len(r.screen) || x < 0 || x >= len(r.screen[y]) {
+		return blankCell
+	}
+	return r.screen[y][x]
+}
+
+// Feed queues the events to be returned by GetChar
+func (r *HeadlessRenderer) Feed(events ...Event) {
+	for _, event := range events {
+		r.events <- event
+	}
+}
+
+// ShouldEmitResizeEvent returns true as there is no SIGWINCH for the headless
+// renderer. Resize events should be delivered via GetChar.
+func (r *HeadlessRenderer) ShouldEmitResizeEvent() bool { return true }
+
+func (r *HeadlessRenderer) Resize(maxHeightFunc func(int) int) {
+	r.mutex.Lock()
+	r.maxHeightFunc = maxHeightFunc
+	r.mutex.Unlock()
+}
+
+// GetChar returns the next event given to Feed. It blocks until an event is
+// fed or CancelGetChar is called.
+func (r *HeadlessRenderer) GetChar(cancellable bool) Event {
+	select {
+	case event := <-r.events:
+		return event
+	case <-r.cancel:
+		return Event{Invalid, 0, nil}
+	}
+}
+
+func (r *HeadlessRenderer) CancelGetChar() {
+	select {
+	case r.cancel <- struct{}{}:
+	default:
+	}
+}
+
+func (r *HeadlessRenderer) MaxX() int {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return r.width
+}
+
+func (r *HeadlessRenderer) MaxY() int {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if r.maxHeightFunc == nil {
+		return r.height
+	}
+	return r.maxHeightFunc(r.height)
+}
+
+func (r *HeadlessRenderer) Size() TermSize {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return TermSize{r.height, r.width, 0, 0}
+}
+
+func (r *HeadlessRenderer) NewWindow(top int, left int, width int, height int, windowType WindowType, borderStyle BorderStyle, erase bool) Window {
+	// Windows are recreated when the terminal is resized
+	r.mutex.Lock()
+	if grid := r.newGrid(); len(grid) != len(r.cells) || len(grid) > 0 && len(grid[0]) != len(r.cells[0]) {
+		r.cells = grid
+	}
+	r.mutex.Unlock()
+
+	normal := ColBorder
+	switch windowType {
+	case WindowList:
+		normal = ColNormal
+	case WindowHeader:
+		normal = ColHeader
+	case WindowFooter:
+		normal = ColFooter
+	case WindowInput:
+		normal = ColInput
+	case WindowPreview:
+		normal = ColPreview
+	}
+	w := &HeadlessWindow{
+		renderer:   r,
+		windowType: windowType,
+		border:     borderStyle,
+		normal:     normal,
+		top:        top,
+		left:       left,
+		width:      max(0, width),
+		height:     max(0, height),
+		tabstop:    r.tabstop}
+	w.Erase()
+	return w
+}
+
+// HeadlessWindow is a window of HeadlessRenderer. It draws on the grid of the
+// renderer in the same way as the full-screen renderer draws on the screen.
+type HeadlessWindow struct {
+	renderer      *HeadlessRenderer
+	windowType    WindowType
+	border        BorderStyle
+	normal        ColorPair
+	top           int
+	left          int
+	width         int
+	height        int
+	posx          int
+	posy          int
+	tabstop       int
+	wrapSign      string
+	wrapSignWidth int
+}
+
+func (w *HeadlessWindow) Top() int                            { return w.top }
+func (w *HeadlessWindow) Left() int                           { return w.left }
+func (w *HeadlessWindow) Width() int                          { return w.width }
+func (w *HeadlessWindow) Height() int                         { return w.height }
+func (w *HeadlessWindow) Refresh()                            {}
+func (w *HeadlessWindow) X() int                              { return w.posx }
+func (w *HeadlessWindow) Y() int                              { return w.posy }
+func (w *HeadlessWindow) LinkBegin(uri string, params string) {}
+func (w *HeadlessWindow) LinkEnd()                            {}
+func (w *HeadlessWindow) FinishFill()                         {}
+func (w *HeadlessWindow) Print(text string)                   { w.print(text, w.normal) }
+func (w *HeadlessWindow) CPrint(color ColorPair, text string) { w.print(text, color) }
+func (w *HeadlessWindow) Fill(text string) FillReturn         { return w.fill(text, w.normal) }
+func (w *HeadlessWindow) DrawBorder()                         { paintBorder(w, w.border, w.windowType, false) }
+func (w *HeadlessWindow) DrawHBorder()                        { paintBorder(w, w.border, w.windowType, true) }
+func (w *HeadlessWindow) SetWrapSign(sign string, width int) {
+	w.wrapSign, w.wrapSignWidth = sign, width
+}
+func (w *HeadlessWindow) EncloseX(x int) bool       { return x >= w.left && x < (w.left+w.width) }
+func (w *HeadlessWindow) EncloseY(y int) bool       { return y >= w.top && y < (w.top+w.height) }
+func (w *HeadlessWindow) Enclose(y int, x int) bool { return w.EncloseX(x) && w.EncloseY(y) }
+
+func (w *HeadlessWindow) DrawHSeparator(row int, windowType WindowType, useBottom bool) {
+	paintHSeparator(w, w.border, row, windowType, useBottom)
+}
+
+func (w *HeadlessWindow) PaintSectionFrame(topContent, bottomContent int, windowType WindowType, edge SectionEdge) {
+	paintSectionFrame(w, w.border, topContent, bottomContent, windowType, edge)
+}
+
+func (w *HeadlessWindow) Move(y int, x int) {
+	w.posx = x
+	w.posy = y
+}
+
+func (w *HeadlessWindow) MoveAndClear(y int, x int) {
+	w.Move(y, x)
+	for i := x; i < w.width; i++ {
+		w.set(i, " ", 1, w.normal)
+	}
+}
+
+func (w *HeadlessWindow) Erase() {
+	for y := 0; y < w.height; y++ {
+		for x := 0; x < w.width; x++ {
+			w.renderer.set(w.top+y, w.left+x, " ", 1, w.normal)
+		}
+	}
+	w.DrawBorder()
+	w.Move(0, 0)
+}
+
+func (w *HeadlessWindow) EraseMaybe() bool {
+	w.Erase()
+	return true
+}
+
+// set puts the grapheme at the column of the current line if it is inside
+// the window
+func (w *HeadlessWindow) set(x int, text string, width int, color ColorPair) {
+	if x < w.width && w.posy < w.height {
+		w.renderer.set(w.top+w.posy, w.left+x, text, width, color)
+	}
+}
+
+func (w *HeadlessWindow) print(text string, color ColorPair) {
+	gr := uniseg.NewGraphemes(text)
+	for gr.Next() {
+		str := gr.Str()
+		c := color
+		switch str {
+		case "\r":
+			str, c = "␍", color.WithAttr(Dim)
+		case "\n":
+			str, c = "␊", color.WithAttr(Dim)
+		default:
+			if rs := gr.Runes(); len(rs) == 1 && rs[0] < ' ' { // ignore control characters
+				continue
+			}
+		}
+		width := util.StringWidth(str)
+		w.set(w.posx, str, width, c)
+		w.posx += width
+	}
+}
+
+func (w *HeadlessWindow) CFill(fg Color, bg Color, ul Color, attr Attr, text string) FillReturn {
+	if fg == colDefault {
+		fg = w.normal.Fg()
+	}
+	if bg == colDefault {
+		bg = w.normal.Bg()
+	}
+	return w.fill(text, NewColorPair(fg, bg, attr).WithUl(ul))
+}
+
+func (w *HeadlessWindow) printWrapSign(color ColorPair) {
+	sign := w.wrapSign
+	if w.wrapSignWidth > w.width {
+		runes, _ := util.Truncate(sign, w.width)
+		sign = string(runes)
+	}
+	w.print(sign, color.WithAttr(Dim))
+}
+
+func (w *HeadlessWindow) fill(text string, color ColorPair) FillReturn {
+	for i, segment := range strings.Split(text, "\n") {
+		for j, wl := range WrapLine(segment, w.posx, w.width, w.tabstop, w.wrapSignWidth) {
+			if i > 0 || j > 0 {
+				w.posy++
+				if w.posy >= w.height {
+					return FillSuspend
+				}
+				w.posx = 0
+				if j > 0 {
+					w.printWrapSign(color)
+				}
+			}
+			if w.posx < w.width {
+				w.print(wl.Text, color)
+			}
+		}
+	}
+	if w.posx >= w.width {
+		w.posy++
+		w.posx = 0
+		return FillNextLine
+	}
+	return FillContinue
+}
@@ -0,0 +1,76 @@
+package tui
+
+import (
+	"testing"
+)
+
+func TestHeadlessRenderer(t *testing.T) {
+	r := NewHeadlessRenderer(12, 5, 8, nil)
+	r.Clear()
+	w := r.NewWindow(0, 0, 12, 5, WindowBase, MakeBorderStyle(BorderRounded, true), false)
+	inner := r.NewWindow(1, 2, 8, 3, WindowList, MakeBorderStyle(BorderNone, true), false)
+	inner.Print("foo")
+	inner.CPrint(NewColorPair(colRed, colDefault, Bold), "bar")
+	inner.Move(1, 0)
+	inner.SetWrapSign("↳", 1)
+	inner.Fill("가나다라\tx")
+	inner.Move(2, 6)
+	inner.Print("a\r\x1bbcd")
+	r.RefreshWindows([]Window{w, inner})
+
+	expected := "" +
+		"╭──────────╮\n" +
+		"│ foobar   │\n" +
+		"│ 가나다라 │\n" +
+		"│ ↳     a␍ │\n" +
+		"╰──────────╯"
+	if snapshot := r.Snapshot(); snapshot != expected {
+		t.Errorf("unexpected snapshot:\n%s\nexpected:\n%s", snapshot, expected)
+	}
+
+	if cell := r.Cell(1, 5); cell.Text != "b" || cell.Color.Fg() != colRed || cell.Color.Attr() != Bold {
+		t.Errorf("unexpected cell: %v", cell)
+	}
+	if cell := r.Cell(2, 3); cell.Text != "" {
+		t.Errorf("expected the second half of a wide character: %v", cell)
+	}
+	for _, x := range []int{2, 9} {
+		if cell := r.Cell(3, x); cell.Color.Attr()&Dim == 0 {
+			t.Errorf("expected dim attribute: %v", cell)
+		}
+	}
+
+	// The snapshot is not updated until the next refresh
+	inner.MoveAndClear(0, 3)
+	if snapshot := r.Snapshot(); snapshot != expected {
+		t.Errorf("snapshot should not change before refresh:\n%s", snapshot)
+	}
+	r.RefreshWindows(nil)
+	if line := r.Cell(1, 5).Text + r.Cell(1, 6).Text; line != "  " {
+		t.Errorf("expected the line to be cleared: %q", line)
+	}
+
+	// Resize
+	r.SetSize(4, 2)
+	r.Clear()
+	r.NewWindow(0, 0, 4, 2, WindowBase, MakeBorderStyle(BorderNone, true), false).Print("foobar")
+	r.RefreshWindows(nil)
+	if snapshot := r.Snapshot(); snapshot != "foob" {
+		t.Errorf("unexpected snapshot: %q", snapshot)
+	}
+}
+
+func TestHeadlessRendererFeed(t *testing.T) {
+	r := NewHeadlessRenderer(80, 24, 8, nil)
+	r.Feed(Key('a'), Enter.AsEvent())
+	if e := r.GetChar(true); e.Type != Rune || e.Char != 'a' {
+		t.Errorf("unexpected event: %v", e)
+	}
+	if e := r.GetChar(true); e.Type != Enter {
+		t.Errorf("unexpected event: %v", e)
+	}
+	r.CancelGetChar()
+	if e := r.GetChar(true); e.Type != Invalid {
+		t.Errorf("unexpected event: %v", e)
+	}
+}
@@ -1130,144 +1130,16 @@ func (w *LightWindow) DrawHBorder() {
 	w.drawBorder(true)
 }
 
-// drawHLine fills row `row` with `line` between optional left/right caps.
-// A zero rune means "no cap"; caps are placed at the very edges of `w`.
-func (w *LightWindow) drawHLine(row int, line, leftCap, rightCap rune, color ColorPair) {
-	w.Move(row, 0)
-	hw := runeWidth(line)
-	width := w.width
-	if leftCap != 0 {
-		w.CPrint(color, string(leftCap))
-		width -= runeWidth(leftCap)
-	}
-	if rightCap != 0 {
-		width -= runeWidth(rightCap)
-	}
-	if width < 0 {
-		width = 0
-	}
-	inner := width / hw
-	rem := width - inner*hw
-	w.CPrint(color, repeat(line, inner)+repeat(' ', rem))
-	if rightCap != 0 {
-		w.CPrint(color, string(rightCap))
-	}
-}
-
 func (w *LightWindow) DrawHSeparator(row int, windowType WindowType, useBottom bool) {
-	if w.height == 0 {
-		return
-	}
-	shape := w.border.shape
-	if shape == BorderNone {
-		return
-	}
-	color := BorderColor(windowType)
-	line := w.border.top
-	if useBottom {
-		line = w.border.bottom
-	}
-	var leftCap, rightCap rune
-	if shape.HasLeft() {
-		leftCap = w.border.leftMid
-	}
-	if shape.HasRight() {
-		rightCap = w.border.rightMid
-	}
-	w.drawHLine(row, line, leftCap, rightCap, color)
+	paintHSeparator(w, w.border, row, windowType, useBottom)
 }
 
 func (w *LightWindow) PaintSectionFrame(topContent, bottomContent int, windowType WindowType, edge SectionEdge) {
-	if w.height == 0 || w.border.shape == BorderNone {
-		return
-	}
-	color := BorderColor(windowType)
-	shape := w.border.shape
-	hasLeft := shape.HasLeft()
-	hasRight := shape.HasRight()
-	rightW := runeWidth(w.border.right)
-	// Content rows: overpaint left/right verticals + their 1-char margin.
-	for row := topContent; row <= bottomContent; row++ {
-		if hasLeft {
-			w.Move(row, 0)
-			w.CPrint(color, string(w.border.left)+" ")
-		}
-		if hasRight {
-			w.Move(row, w.width-rightW-1)
-			w.CPrint(color, " "+string(w.border.right))
-		}
-	}
-	if edge == SectionEdgeTop && shape.HasTop() {
-		var leftCap, rightCap rune
-		if hasLeft {
-			leftCap = w.border.topLeft
-		}
-		if hasRight {
-			rightCap = w.border.topRight
-		}
-		w.drawHLine(0, w.border.top, leftCap, rightCap, color)
-	}
-	if edge == SectionEdgeBottom && shape.HasBottom() {
-		var leftCap, rightCap rune
-		if hasLeft {
-			leftCap = w.border.bottomLeft
-		}
-		if hasRight {
-			rightCap = w.border.bottomRight
-		}
-		w.drawHLine(w.height-1, w.border.bottom, leftCap, rightCap, color)
-	}
+	paintSectionFrame(w, w.border, topContent, bottomContent, windowType, edge)
 }
 
 func (w *LightWindow) drawBorder(onlyHorizontal bool) {
-	if w.height == 0 {
-		return
-	}
-	shape := w.border.shape
-	if shape == BorderNone {
-		return
-	}
-	color := BorderColor(w.windowType)
-	hasLeft := shape.HasLeft()
-	hasRight := shape.HasRight()
-
-	if shape.HasTop() {
-		var leftCap, rightCap rune
-		if hasLeft {
-			leftCap = w.border.topLeft
-		}
-		if hasRight {
-			rightCap = w.border.topRight
-		}
-		w.drawHLine(0, w.border.top, leftCap, rightCap, color)
-	}
-	if !onlyHorizontal && (hasLeft || hasRight) {
-		vw := runeWidth(w.border.left)
-		for y := 0; y < w.height; y++ {
-			// Corner rows are already painted by drawHLine above / below.
-			if (y == 0 && shape.HasTop()) || (y == w.height-1 && shape.HasBottom()) {
-				continue
-			}
-			if hasLeft {
-				w.Move(y, 0)
-				w.CPrint(color, string(w.border.left)+" ")
-			}
-			if hasRight {
-				w.Move(y, w.width-vw-1)
-				w.CPrint(color, " "+string(w.border.right))
-			}
-		}
-	}
-	if shape.HasBottom() {
-		var leftCap, rightCap rune
-		if hasLeft {
-			leftCap = w.border.bottomLeft
-		}
-		if hasRight {
-			rightCap = w.border.bottomRight
-		}
-		w.drawHLine(w.height-1, w.border.bottom, leftCap, rightCap, color)
-	}
+	paintBorder(w, w.border, w.windowType, onlyHorizontal)
 }
 
 func (w *LightWindow) csi(code string) string {
@@ -233,7 +233,11 @@ const (
 	ClickHeader
 	ClickFooter
 	Multi
+	NormalMode
+	InsertMode
 	Every
+	Idle
+	DebouncedChange
 	ResultFinal
 )
 
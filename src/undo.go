@@ -0,0 +1,105 @@
+package fzf
+
+import (
+	"slices"
+)
+
+// queryState is a snapshot of the query that can be restored by undo and redo
+type queryState struct {
+	input    []rune
+	cx       int
+	override *[]rune // Query set by search action
+}
+
+func (s queryState) equals(other queryState) bool {
+	if string(s.input) != string(other.input) {
+		return false
+	}
+	if s.override == nil || other.override == nil {
+		return s.override == other.override
+	}
+	return string(*s.override) == string(*other.override)
+}
+
+// undoHistory keeps the previous states of the query for undo and redo
+// actions
+type undoHistory struct {
+	undos    []queryState
+	redos    []queryState
+	coalesce bool // Whether the next insertion can be merged into the last entry
+}
+
+// record saves the state of the query before a change. If coalesce is true,
+// the change is merged into the last one when it was also coalescable, so
+// that consecutive insertions of characters are undone at once.
+func (h *undoHistory) record(state queryState, coalesce bool) {
+	h.redos = nil
+	if coalesce && h.coalesce && len(h.undos) > 0 {
+		return
+	}
+	h.coalesce = coalesce
+	if len(h.undos) >= maxUndoHistory {
+		h.undos = slices.Delete(h.undos, 0, len(h.undos)-maxUndoHistory+1)
+	}
+	h.undos = append(h.undos, state)
+}
+
+// breakCoalesce prevents the next insertion from being merged into the last
+// entry, e.g. when the cursor is moved
+func (h *undoHistory) breakCoalesce() {
+	h.coalesce = false
+}
+
+// undo returns the state to restore, saving the current state for redo
+func (h *undoHistory) undo(current queryState) (queryState, bool) {
+	h.coalesce = false
+	if len(h.undos) == 0 {
+		return queryState{}, false
+	}
+	state := h.undos[len(h.undos)-1]
+	h.undos = h.undos[:len(h.undos)-1]
+	h.redos = append(h.redos, current)
+	return state, true
+}
+
+// redo returns the state to restore, saving the current state for undo
+func (h *undoHistory) redo(current queryState) (queryState, bool) {
+	h.coalesce = false
+	if len(h.redos) == 0 {
+		return queryState{}, false
+	}
+	state := h.redos[len(h.redos)-1]
+	h.redos = h.redos[:len(h.redos)-1]
+	h.undos = append(h.undos, current)
+	return state, true
+}
+
+func (t *Terminal) queryState() queryState {
+	return queryState{input: copySlice(t.input), cx: t.cx, override: t.inputOverride}
+}
+
+// recordQueryChange records the previous state of the query if it has been
+// changed
+func (t *Terminal) recordQueryChange(previous queryState, coalesce bool) {
+	if t.inputless {
+		return
+	}
+	if !previous.equals(t.queryState()) {
+		t.undoHistory.record(previous, coalesce)
+	} else if previous.cx != t.cx {
+		t.undoHistory.breakCoalesce()
+	}
+}
+
+// restoreQueryState restores the state of the query. It returns true if the
+// search query has been changed.
+func (t *Terminal) restoreQueryState(state queryState) bool {
+	changed := !state.equals(t.queryState())
+	t.input = state.input
+	t.cx = state.cx
+	t.inputOverride = state.override
+	if t.vi != nil {
+		t.constrainViCursor()
+	}
+	return changed
+}
@@ -0,0 +1,84 @@
+package fzf
+
+import (
+	"testing"
+)
+
+func TestUndoHistory(t *testing.T) {
+	state := func(str string) queryState {
+		return queryState{input: []rune(str), cx: len(str)}
+	}
+	check := func(state queryState, ok bool, expected string) {
+		t.Helper()
+		if !ok || string(state.input) != expected {
+			t.Errorf("expected %q, got %q (%v)", expected, string(state.input), ok)
+		}
+	}
+
+	h := undoHistory{}
+	if _, ok := h.undo(state("")); ok {
+		t.Error("nothing to undo")
+	}
+
+	// Consecutive insertions are coalesced
+	h.record(state(""), true)
+	h.record(state("f"), true)
+	h.record(state("fo"), true)
+	h.record(state("foo"), false)
+	h.record(state("foo bar"), true)
+	h.breakCoalesce()
+	h.record(state("foo barx"), true)
+	if len(h.undos) != 4 {
+		t.Errorf("expected 4 entries, got %d", len(h.undos))
+	}
+
+	s, ok := h.undo(state("foo barxy"))
+	check(s, ok, "foo barx")
+	s, ok = h.undo(s)
+	check(s, ok, "foo bar")
+	s, ok = h.undo(s)
+	check(s, ok, "foo")
+	s, ok = h.redo(s)
+	check(s, ok, "foo bar")
+	s, ok = h.redo(s)
+	check(s, ok, "foo barx")
+	s, ok = h.redo(s)
+	check(s, ok, "foo barxy")
+	if _, ok := h.redo(s); ok {
+		t.Error("nothing to redo")
+	}
+
+	// A new change clears the redo history
+	s, _ = h.undo(s)
+	h.record(s, false)
+	if len(h.redos) != 0 {
+		t.Error("redo history should be cleared")
+	}
+
+	// The number of entries is limited
+	h = undoHistory{}
+	for range maxUndoHistory + 10 {
+		h.record(state("foo"), false)
+	}
+	if len(h.undos) != maxUndoHistory {
+		t.Errorf("expected %d entries, got %d", maxUndoHistory, len(h.undos))
+	}
+}
+
+func TestQueryStateEquals(t *testing.T) {
+	foo := []rune("foo")
+	bar := []rune("bar")
+	for _, test := range []struct {
+		a, b     queryState
+		expected bool
+	}{
+		{queryState{input: foo}, queryState{input: []rune("foo"), cx: 1}, true},
+		{queryState{input: foo}, queryState{input: bar}, false},
+		{queryState{input: foo, override: &bar}, queryState{input: foo}, false},
+		{queryState{input: foo, override: &bar}, queryState{input: foo, override: &[]rune{'b', 'a', 'r'}}, true},
+	} {
+		if test.a.equals(test.b) != test.expected {
+			t.Errorf("%v == %v should be %v", test.a, test.b, test.expected)
+		}
+	}
+}
@@ -0,0 +1,429 @@
+package fzf
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/junegunn/fzf/src/tui"
+	"github.com/junegunn/fzf/src/util"
+)
+
+type viMode int
+
+const (
+	viInsert viMode = iota
+	viNormal
+)
+
+func (mode viMode) String() string {
+	if mode == viNormal {
+		return "normal"
+	}
+	return "insert"
+}
+
+// viState is the state of the modal query editing enabled by --vi-mode
+type viState struct {
+	mode     viMode
+	count    int  // Count typed before the operator or the motion
+	operator rune // Pending operator (d, c, or y)
+	opCount  int  // Count typed before the operator
+	find     rune // Pending f, F, t, or T motion waiting for a character
+}
+
+const (
+	viModePlaceholder = "{fzf:mode}"
+
+	// Maximum count of a command
+	viMaxCount = 9999
+)
+
+func (vi *viState) pending() bool {
+	return vi.count > 0 || vi.operator != 0 || vi.find != 0
+}
+
+func (vi *viState) reset() {
+	vi.count = 0
+	vi.operator = 0
+	vi.opCount = 0
+	vi.find = 0
+}
+
+func (t *Terminal) viMode() viMode {
+	if t.vi == nil {
+		return viInsert
+	}
+	return t.vi.mode
+}
+
+func (t *Terminal) setViMode(mode viMode) {
+	t.vi.reset()
+	if t.vi.mode == mode {
+		return
+	}
+	t.vi.mode = mode
+	if mode == viNormal {
+		t.cx--
+	}
+	t.constrainViCursor()
+	t.prompt, t.promptLen = t.parsePrompt(t.promptString)
+}
+
+// constrainViCursor keeps the cursor on a character in normal mode
+func (t *Terminal) constrainViCursor() {
+	if t.viMode() == viNormal {
+		t.cx = max(0, min(t.cx, len(t.input)-1))
+	} else {
+		t.cx = max(0, min(t.cx, len(t.input)))
+	}
+}
+
+// viKey handles the key event in vi mode. It returns true if the key is
+// consumed, along with the actions to perform.
+func (t *Terminal) viKey(event tui.Event) (bool, []*action) {
+	if event.Type >= tui.Invalid {
+		return false, nil
+	}
+	if t.vi.mode == viInsert {
+		if event.Type == tui.Esc {
+			t.setViMode(viNormal)
+			return true, nil
+		}
+		return false, nil
+	}
+	if event.Type != tui.Rune {
+		// Cancel the pending command
+		if t.vi.pending() {
+			t.vi.reset()
+			return event.Type == tui.Esc, nil
+		}
+		// ctrl-r is redo unless bound by the user
+		if _, bound := t.keymap[event.Comparable()]; !bound && event.Type == tui.CtrlR {
+			return true, []*action{{t: actRedo}}
+		}
+		return false, nil
+	}
+	return true, t.viNormalKey(event.Char)
+}
+
+// viKeyWithUndo handles the key in vi mode and records the change of the
+// query for undo
+func (t *Terminal) viKeyWithUndo(event tui.Event) (bool, []*action) {
+	previousState := t.queryState()
+	consumed, actions := t.viKey(event)
+	t.recordQueryChange(previousState, false)
+	return consumed, actions
+}
+
+func viCharClass(r rune, bigWord bool) int {
+	if unicode.IsSpace(r) {
+		return 0
+	}
+	if bigWord || r != '_' && !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+		return 1
+	}
+	return 2
+}
+
+// viNextWordStart returns the position of the start of the next word (w, W)
+func viNextWordStart(input []rune, pos int, bigWord bool) int {
+	if pos >= len(input) {
+		return len(input)
+	}
+	class := viCharClass(input[pos], bigWord)
+	for pos < len(input) && class > 0 && viCharClass(input[pos], bigWord) == class {
+		pos++
+	}
+	for pos < len(input) && unicode.IsSpace(input[pos]) {
+		pos++
+	}
+	return pos
+}
+
+// viWordEnd returns the position of the end of the word (e, E)
+func viWordEnd(input []rune, pos int, bigWord bool) int {
+	pos++
+	for pos < len(input) && unicode.IsSpace(input[pos]) {
+		pos++
+	}
+	if pos >= len(input) {
+		return max(0, len(input)-1)
+	}
+	class := viCharClass(input[pos], bigWord)
+	for pos+1 < len(input) && viCharClass(input[pos+1], bigWord) == class {
+		pos++
+	}
+	return pos
+}
+
+// viPrevWordStart returns the position of the start of the previous word (b, B)
+func viPrevWordStart(input []rune, pos int, bigWord bool) int {
+	pos--
+	for pos > 0 && unicode.IsSpace(input[pos]) {
+		pos--
+	}
+	if pos <= 0 {
+		return 0
+	}
+	class := viCharClass(input[pos], bigWord)
+	for pos > 0 && viCharClass(input[pos-1], bigWord) == class {
+		pos--
+	}
+	return pos
+}
+
+// viFind returns the position of the count-th occurrence of the character
+// for f, F, t, and T motions
+func viFind(input []rune, pos int, motion rune, char rune, count int) (int, bool) {
+	forward := motion == 'f' || motion == 't'
+	for found := 0; found < count; {
+		if forward {
+			pos++
+		} else {
+			pos--
+		}
+		if pos < 0 || pos >= len(input) {
+			return 0, false
+		}
+		if input[pos] == char {
+			found++
+		}
+	}
+	switch motion {
+	case 't':
+		pos--
+	case 'T':
+		pos++
+	}
+	return pos, true
+}
+
+// viMotion returns the destination of the motion and whether the character
+// at the destination is included in the range of an operator
+func (t *Terminal) viMotion(motion rune, count int) (int, bool, bool) {
+	input := t.input
+	pos := t.cx
+	switch motion {
+	case 'h':
+		return max(0, pos-count), false, true
+	case 'l', ' ':
+		if t.vi.operator != 0 {
+			return min(len(input), pos+count), false, true
+		}
+		return min(max(0, len(input)-1), pos+count), false, true
+	case '0':
+		return 0, false, true
+	case '^':
+		for idx, r := range input {
+			if !unicode.IsSpace(r) {
+				return idx, false, true
+			}
+		}
+		return 0, false, true
+	case '$':
+		if t.vi.operator != 0 {
+			return len(input), false, true
+		}
+		return max(0, len(input)-1), false, true
+	case 'w', 'W':
+		bigWord := motion == 'W'
+		// cw on a word works like ce
+		if t.vi.operator == 'c' && pos < len(input) && !unicode.IsSpace(input[pos]) {
+			for i := range count {
+				// Only the character is changed at the end of a word
+				if i == 0 && (pos+1 >= len(input) || viCharClass(input[pos+1], bigWord) != viCharClass(input[pos], bigWord)) {
+					continue
+				}
+				pos = viWordEnd(input, pos, bigWord)
+			}
+			return pos, true, true
+		}
+		for range count {
+			pos = viNextWordStart(input, pos, bigWord)
+		}
+		return pos, false, true
+	case 'e', 'E':
+		for range count {
+			pos = viWordEnd(input, pos, motion == 'E')
+		}
+		return pos, true, true
+	case 'b', 'B':
+		for range count {
+			pos = viPrevWordStart(input, pos, motion == 'B')
+		}
+		return pos, false, true
+	}
+	return 0, false, false
+}
+
+// viApply applies the operator to the range of the query
+func (t *Terminal) viApply(operator rune, begin int, end int) {
+	begin = max(0, begin)
+	end = min(len(t.input), end)
+	if begin < end {
+		t.yanked = copySlice(t.input[begin:end])
+		if operator != 'y' {
+			t.input = append(copySlice(t.input[:begin]), t.input[end:]...)
+		}
+	}
+	t.cx = begin
+	if operator == 'c' {
+		t.setViMode(viInsert)
+	}
+	t.constrainViCursor()
+}
+
+// viNormalKey handles the key typed in normal mode and returns the actions
+// to perform
+func (t *Terminal) viNormalKey(r rune) []*action {
+	vi := t.vi
+	count := min(viMaxCount, max(1, vi.count)*max(1, vi.opCount))
+	if vi.find != 0 {
+		motion := vi.find
+		vi.find = 0
+		if pos, found := viFind(t.input, t.cx, motion, r, count); found {
+			t.viMove(pos, motion == 'f' || motion == 't')
+		} else {
+			vi.reset()
+		}
+		return nil
+	}
+	if r >= '1' && r <= '9' || r == '0' && vi.count > 0 {
+		vi.count = min(viMaxCount, vi.count*10+int(r-'0'))
+		return nil
+	}
+
+	operator := vi.operator
+	switch r {
+	case 'd', 'c', 'y':
+		if operator == r {
+			// dd, cc, yy
+			vi.reset()
+			t.viApply(r, 0, len(t.input))
+			return nil
+		} else if operator == 0 {
+			vi.operator = r
+			vi.opCount = vi.count
+			vi.count = 0
+			return nil
+		}
+	case 'D', 'C':
+		vi.reset()
+		t.viApply(unicode.ToLower(r), t.cx, len(t.input))
+		return nil
+	case 'Y':
+		vi.reset()
+		t.viApply('y', 0, len(t.input))
+		return nil
+	case 'S':
+		vi.reset()
+		t.viApply('c', 0, len(t.input))
+		return nil
+	case 'x':
+		vi.reset()
+		t.viApply('d', t.cx, t.cx+count)
+		return nil
+	case 's':
+		vi.reset()
+		t.viApply('c', t.cx, t.cx+count)
+		return nil
+	case 'X':
+		vi.reset()
+		if t.cx > 0 {
+			t.viApply('d', t.cx-count, t.cx)
+		}
+		return nil
+	case 'i', 'a', 'I', 'A':
+		if operator == 0 {
+			t.setViMode(viInsert)
+			switch r {
+			case 'a':
+				t.cx = min(t.cx+1, len(t.input))
+			case 'A':
+				t.cx = len(t.input)
+			case 'I':
+				t.cx, _, _ = t.viMotion('^', 1)
+			}
+			return nil
+		}
+	case 'p', 'P':
+		if operator == 0 && len(t.yanked) > 0 {
+			vi.reset()
+			pos := t.cx
+			if r == 'p' && len(t.input) > 0 {
+				pos++
+			}
+			// The query is truncated to maxPatternLength anyway
+			count = min(count, max(1, maxPatternLength/len(t.yanked)))
+			str := []rune(strings.Repeat(string(t.yanked), count))
+			t.input = append(append(copySlice(t.input[:pos]), str...), t.input[pos:]...)
+			t.cx = pos + len(str) - 1
+			return nil
+		}
+	case 'j', 'k':
+		if operator == 0 {
+			vi.reset()
+			return t.viVerticalMove(r == 'k', count)
+		}
+	case 'u':
+		if operator == 0 {
+			vi.reset()
+			return viRepeat(actUndo, count)
+		}
+	case 'f', 'F', 't', 'T':
+		vi.find = r
+		return nil
+	default:
+		if pos, inclusive, ok := t.viMotion(r, count); ok {
+			t.viMove(pos, inclusive)
+			return nil
+		}
+	}
+	vi.reset()
+	return nil
+}
+
+// viVerticalMove returns the action to move the cursor on the list by count
+// lines (j, k)
+func (t *Terminal) viVerticalMove(up bool, count int) []*action {
+	if count == 1 {
+		if up {
+			return []*action{{t: actUp}}
+		}
+		return []*action{{t: actDown}}
+	}
+	offset := count
+	if !up {
+		offset = -count
+	}
+	if t.layout != layoutDefault {
+		offset = -offset
+	}
+	pos := util.Constrain(t.cy+offset, 0, max(0, t.merger.Length()-1))
+	return []*action{{t: actPosition, a: strconv.Itoa(pos + 1)}}
+}
+
+func viRepeat(actionType actionType, count int) []*action {
+	actions := make([]*action, count)
+	for i := range actions {
+		actions[i] = &action{t: actionType}
+	}
+	return actions
+}
+
+// viMove moves the cursor, or applies the pending operator to the range
+func (t *Terminal) viMove(pos int, inclusive bool) {
+	operator := t.vi.operator
+	t.vi.reset()
+	if operator == 0 {
+		t.cx = pos
+		t.constrainViCursor()
+		return
+	}
+	begin, end := min(t.cx, pos), max(t.cx, pos)
+	if inclusive {
+		end++
+	}
+	t.viApply(operator, begin, end)
+}
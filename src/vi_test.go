@@ -0,0 +1,135 @@
+package fzf
+
+import (
+	"testing"
+
+	"github.com/junegunn/fzf/src/tui"
+)
+
+func TestViNormalMode(t *testing.T) {
+	for _, test := range []struct {
+		input    string
+		cx       int
+		keys     string
+		expected string
+		cursor   int
+		mode     viMode
+	}{
+		{"foo bar baz", 0, "w", "foo bar baz", 4, viNormal},
+		{"foo bar baz", 0, "2w", "foo bar baz", 8, viNormal},
+		{"foo bar baz", 0, "e", "foo bar baz", 2, viNormal},
+		{"foo bar baz", 10, "b", "foo bar baz", 8, viNormal},
+		{"foo bar baz", 10, "3b", "foo bar baz", 0, viNormal},
+		{"foo.bar baz", 0, "w", "foo.bar baz", 3, viNormal},
+		{"foo.bar baz", 0, "W", "foo.bar baz", 8, viNormal},
+		{"foo bar baz", 4, "$", "foo bar baz", 10, viNormal},
+		{"  foo bar", 6, "^", "  foo bar", 2, viNormal},
+		{"foo bar baz", 4, "0", "foo bar baz", 0, viNormal},
+		{"foo bar baz", 0, "fa", "foo bar baz", 5, viNormal},
+		{"foo bar baz", 0, "2fa", "foo bar baz", 9, viNormal},
+		{"foo bar baz", 0, "tb", "foo bar baz", 3, viNormal},
+		{"foo bar baz", 10, "Fo", "foo bar baz", 2, viNormal},
+		{"foo bar baz", 0, "dw", "bar baz", 0, viNormal},
+		{"foo bar baz", 0, "2dw", "baz", 0, viNormal},
+		{"foo bar baz", 0, "d2w", "baz", 0, viNormal},
+		{"foo bar baz", 4, "d$", "foo ", 3, viNormal},
+		{"foo bar baz", 4, "D", "foo ", 3, viNormal},
+		{"foo bar baz", 4, "de", "foo  baz", 4, viNormal},
+		{"foo bar baz", 8, "db", "foo baz", 4, viNormal},
+		{"foo bar baz", 0, "dfa", "r baz", 0, viNormal},
+		{"foo bar baz", 0, "dd", "", 0, viNormal},
+		{"foo bar baz", 0, "x", "oo bar baz", 0, viNormal},
+		{"foo bar baz", 0, "3x", " bar baz", 0, viNormal},
+		{"foo bar baz", 10, "x", "foo bar ba", 9, viNormal},
+		{"foo bar baz", 4, "X", "foobar baz", 3, viNormal},
+		{"foo bar baz", 4, "cw", "foo  baz", 4, viInsert},
+		{"foo bar baz", 6, "cw", "foo ba baz", 6, viInsert},
+		{"foo bar baz", 4, "c$", "foo ", 4, viInsert},
+		{"foo bar baz", 4, "C", "foo ", 4, viInsert},
+		{"foo bar baz", 4, "cc", "", 0, viInsert},
+		{"foo bar baz", 4, "S", "", 0, viInsert},
+		{"foo bar baz", 4, "s", "foo ar baz", 4, viInsert},
+		{"foo bar baz", 4, "i", "foo bar baz", 4, viInsert},
+		{"foo bar baz", 4, "a", "foo bar baz", 5, viInsert},
+		{"  foo bar", 6, "I", "  foo bar", 2, viInsert},
+		{"foo bar baz", 4, "A", "foo bar baz", 11, viInsert},
+		{"foo bar baz", 0, "ywP", "foo foo bar baz", 3, viNormal},
+		{"foo bar baz", 0, "ywp", "ffoo oo bar baz", 4, viNormal},
+		{"foo bar baz", 0, "dw$p", "bar bazfoo ", 10, viNormal},
+		{"foo bar baz", 0, "dzw", "foo bar baz", 4, viNormal},
+	} {
+		term := Terminal{input: []rune(test.input), cx: test.cx, vi: &viState{mode: viNormal}}
+		for _, r := range test.keys {
+			term.viKey(tui.Key(r))
+		}
+		if string(term.input) != test.expected || term.cx != test.cursor || term.vi.mode != test.mode {
+			t.Errorf("%q (%d) + %s: expected: %q (%d, %s), actual: %q (%d, %s)",
+				test.input, test.cx, test.keys, test.expected, test.cursor, test.mode, string(term.input), term.cx, term.vi.mode)
+		}
+	}
+}
+
+func TestViModeSwitch(t *testing.T) {
+	term := Terminal{input: []rune("foo"), cx: 3, vi: &viState{}, merger: NewMerger(nil, [][]Result{make([]Result, 10)}, false, false, revision{}, 0, 0)}
+	if consumed, _ := term.viKey(tui.Key('x')); consumed {
+		t.Error("should not consume keys in insert mode")
+	}
+	if consumed, _ := term.viKey(tui.Esc.AsEvent()); !consumed || term.vi.mode != viNormal || term.cx != 2 {
+		t.Errorf("should switch to normal mode: %s, %d", term.vi.mode, term.cx)
+	}
+
+	// Cancel the pending operator
+	term.viKey(tui.Key('d'))
+	if consumed, _ := term.viKey(tui.Esc.AsEvent()); !consumed || term.vi.pending() {
+		t.Error("should cancel the pending operator")
+	}
+	if consumed, _ := term.viKey(tui.Esc.AsEvent()); consumed {
+		t.Error("should not consume esc without pending command")
+	}
+
+	if _, actions := term.viKey(tui.Key('3')); actions != nil {
+		t.Errorf("unexpected actions: %v", actions)
+	}
+	if _, actions := term.viKey(tui.Key('k')); len(actions) != 1 || actions[0].t != actPosition || actions[0].a != "4" {
+		t.Errorf("unexpected actions: %v", actions)
+	}
+	if _, actions := term.viKey(tui.Key('k')); len(actions) != 1 || actions[0].t != actUp {
+		t.Errorf("unexpected actions: %v", actions)
+	}
+
+	// The count is limited
+	for _, r := range "99999999999999999999j" {
+		term.viKey(tui.Key(r))
+	}
+	if _, actions := term.viKey(tui.Key('j')); len(actions) != 1 || actions[0].t != actDown {
+		t.Errorf("unexpected actions: %v", actions)
+	}
+	term.cy = 5
+	for _, r := range "99999999999999999999k" {
+		if _, actions := term.viKey(tui.Key(r)); r == 'k' && (len(actions) != 1 || actions[0].a != "10") {
+			t.Errorf("unexpected actions: %v", actions)
+		}
+	}
+	term.input = []rune("foo")
+	term.cx = 0
+	term.yanked = []rune("bar")
+	for _, r := range "99999999999999999999P" {
+		term.viKey(tui.Key(r))
+	}
+	if len(term.input) > maxPatternLength+len(term.yanked) {
+		t.Errorf("query too long: %d", len(term.input))
+	}
+}
+
+func TestViRedoKey(t *testing.T) {
+	term := Terminal{vi: &viState{mode: viNormal}}
+	if consumed, actions := term.viKey(tui.CtrlR.AsEvent()); !consumed || len(actions) != 1 || actions[0].t != actRedo {
+		t.Errorf("unexpected actions: %v", actions)
+	}
+
+	// User binding takes precedence
+	term.keymap = map[tui.Event][]*action{tui.CtrlR.AsEvent(): toActions(actReload)}
+	if consumed, actions := term.viKey(tui.CtrlR.AsEvent()); consumed || actions != nil {
+		t.Errorf("unexpected actions: %v", actions)
+	}
+}